@@ -1,9 +1,6 @@
 package schematypes
 
-import (
-	"fmt"
-	"reflect"
-)
+import "reflect"
 
 // An Array struct represents the JSON schema for an array.
 type Array struct {
@@ -27,36 +24,53 @@ func (a Array) Schema() map[string]interface{} {
 // Validate the given data, this will return nil if data satisfies this schema.
 // Otherwise, Validate(data) returns a ValidationError instance.
 func (a Array) Validate(data interface{}) error {
+	return a.validate(&validation{}, data)
+}
+
+func (a Array) validate(v *validation, data interface{}) error {
 	value := reflect.ValueOf(data)
 
 	if value.Kind() != reflect.Array && value.Kind() != reflect.Slice {
-		return singleIssue("", "Expected array or slice at {path}")
+		return singleIssue("type", "Expected array or slice at {path}")
 	}
 
 	e := &ValidationError{}
 
+	// For uniqueness we track the index of the last occurrence of each distinct
+	// value by hash, so that each item is compared with a few values only.
+	var seen map[uint64][]int
+	if a.Unique {
+		seen = make(map[uint64][]int, value.Len())
+	}
+
 	// Validate all elements
 	N := value.Len()
-	for i := 0; i < N; i++ {
+	for i := 0; i < N && !v.full(e); i++ {
 		vi := value.Index(i).Interface()
-		e.addIssuesWithPrefix(a.Items.Validate(vi), "[%d]", i)
+		l := itemLocation(i)
+		e.addIssuesAt(v.validateNested(a.Items, vi, l), l)
 
 		// Test for uniqueness if required
 		if a.Unique {
-			// If testing we start from i + 1, so we don't test with i. Besides we
-			// can safely assume everything before i has been test against i.
-			for j := i + 1; j < N; j++ {
-				vj := value.Index(j).Interface()
-				if reflect.DeepEqual(vi, vj) {
-					e.addIssue(fmt.Sprintf("[%d]", i),
-						"Array doesn't have unique items, index %d and %d are equal", i, j)
+			h := jsonHash(vi)
+			duplicate := false
+			for k, j := range seen[h] {
+				if jsonEqual(value.Index(j).Interface(), vi) {
+					e.addIssueAt(indexElement(j), "uniqueItems",
+						"Array doesn't have unique items, index %d and %d are equal", j, i)
+					seen[h][k] = i
+					duplicate = true
 					break
 				}
 			}
+			if !duplicate {
+				seen[h] = append(seen[h], i) // len(seen[h]) > 1 only on collisions
+			}
 		}
 	}
 
 	if len(e.issues) > 0 {
+		e.Sort()
 		return e
 	}
 	return nil
@@ -67,17 +81,25 @@ func (a Array) Map(data, target interface{}) error {
 	if err := a.Validate(data); err != nil {
 		return err
 	}
+	return a.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (a Array) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(a, data, target); ok {
+		return err
+	}
 
 	// Ensure that we have a pointer as input
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(a, target)
 	}
 	val := ptr.Elem()
 
 	// Ensure that we have an array type
 	if val.Kind() != reflect.Slice {
-		return ErrTypeMismatch
+		return typeMismatch(a, target)
 	}
 	elem := val.Type().Elem()
 
@@ -91,13 +113,25 @@ func (a Array) Map(data, target interface{}) error {
 		val.Set(reflect.Append(val, reflect.Zero(elem)))
 		v := value.Index(i).Interface()
 		vt := val.Index(i).Addr().Interface()
-		if err := a.Items.Map(v, vt); err != nil {
-			if err != ErrTypeMismatch {
-				panic("Internal error, this should have been caught in Validate()")
-			}
-			return err
+		if err := mapValidated(a.Items, v, vt); err != nil {
+			return prefixMapError(err, itemLocation(i))
 		}
 	}
 
 	return nil
 }
+
+func (a Array) unmap(value reflect.Value) (interface{}, error) {
+	if value.Kind() != reflect.Array && value.Kind() != reflect.Slice {
+		return nil, unmapMismatch(a, value)
+	}
+	result := make([]interface{}, value.Len())
+	for i := range result {
+		item, err := unmapValue(a.Items, value.Index(i))
+		if err != nil {
+			return nil, prefixMapError(err, itemLocation(i))
+		}
+		result[i] = item
+	}
+	return result, nil
+}
@@ -132,3 +132,38 @@ func TestUniqueIntegerArray(t *testing.T) {
 		},
 	}.Test(t)
 }
+
+func TestUniqueArrayDuplicates(t *testing.T) {
+	s := Array{
+		Items:  AnyOf{Integer{Minimum: 0, Maximum: 10}, Object{AdditionalProperties: true}},
+		Unique: true,
+	}
+	err := s.Validate([]interface{}{
+		1, float64(2), float64(1), int64(1),
+		map[string]interface{}{"a": 1, "b": []interface{}{"x"}},
+		map[string]interface{}{"b": []interface{}{"x"}, "a": float64(1)},
+	})
+	e, ok := err.(*ValidationError)
+	assert(ok, "Expected a ValidationError")
+	msgs := []string{}
+	for _, issue := range e.Issues("") {
+		msgs = append(msgs, issue.String())
+	}
+	assertJSON(msgs, `[
+		"Array doesn't have unique items, index 0 and 2 are equal",
+		"Array doesn't have unique items, index 2 and 3 are equal",
+		"Array doesn't have unique items, index 4 and 5 are equal"
+	]`, "Unexpected issues: ", msgs)
+}
+
+func BenchmarkUniqueArray(b *testing.B) {
+	s := Array{Items: Integer{Minimum: 0, Maximum: 1 << 30}, Unique: true}
+	data := make([]interface{}, 10000)
+	for i := range data {
+		data[i] = float64(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Validate(data)
+	}
+}
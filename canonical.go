@@ -0,0 +1,172 @@
+package schematypes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Canonicalize returns the JSON schema for schema in a canonical form, such
+// that schemas that are equal have the same canonical form, regardless of how
+// they were constructed. The canonical form is compact JSON where:
+//
+//   - object keys are sorted,
+//   - numbers are written in their shortest form, so int64(1), float64(1)
+//     and json.Number("1.0") are all written as 1,
+//   - lists of any Go type are written as arrays, and
+//   - the values of "required", "enum" and "type" in a schema are sorted, as
+//     their order doesn't matter.
+//
+// This panics if schema.Schema() returns values that can't be written as
+// JSON, such as NaN, which isn't the case for the schema types in this package.
+func Canonicalize(schema Schema) []byte {
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(canonicalSchema(schema.Schema())); err != nil {
+		panic(fmt.Sprintf("schema can't be written as JSON, error: %s", err))
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+// Fingerprint returns the SHA-256 hash of the canonical form of schema as a
+// hex string. Schemas that are equal have the same fingerprint, so this is
+// useful as a cache key or for detecting differences between the schemas used
+// by different services. See Canonicalize for details.
+func Fingerprint(schema Schema) string {
+	sum := sha256.Sum256(Canonicalize(schema))
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalSchema returns the JSON schema s in canonical form, with the
+// sub-schemas of keywords in canonical form.
+func canonicalSchema(s interface{}) interface{} {
+	m, ok := canonicalValue(s).(map[string]interface{})
+	if !ok {
+		return canonicalValue(s) // boolean schemas
+	}
+	for key, value := range m {
+		switch key {
+		case "properties", "patternProperties", "definitions", "$defs", "dependencies":
+			if schemas, ok := value.(map[string]interface{}); ok {
+				for k, v := range schemas {
+					schemas[k] = canonicalSchema(v)
+				}
+			}
+		case "items", "additionalItems", "additionalProperties", "not", "allOf", "anyOf", "oneOf":
+			if schemas, ok := value.([]interface{}); ok {
+				for i, v := range schemas {
+					schemas[i] = canonicalSchema(v)
+				}
+			} else {
+				m[key] = canonicalSchema(value)
+			}
+		case "required", "enum", "type":
+			if list, ok := value.([]interface{}); ok {
+				sortCanonical(list)
+			}
+		}
+	}
+	return m
+}
+
+// canonicalValue returns a copy of the JSON value v as map[string]interface{},
+// []interface{}, json.Number in shortest form, string, bool or nil.
+func canonicalValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case nil, string, bool:
+		return value
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return json.Number(strconv.FormatInt(i, 10))
+		}
+		if f, err := value.Float64(); err == nil {
+			return canonicalFloat(f)
+		}
+		return value
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Number(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return json.Number(strconv.FormatUint(rv.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		return canonicalFloat(rv.Float())
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			result := make(map[string]interface{}, rv.Len())
+			for _, key := range rv.MapKeys() {
+				result[key.String()] = canonicalValue(rv.MapIndex(key).Interface())
+			}
+			return result
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return []interface{}{}
+		}
+		result := make([]interface{}, rv.Len())
+		for i := range result {
+			result[i] = canonicalValue(rv.Index(i).Interface())
+		}
+		return result
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return canonicalValue(rv.Elem().Interface())
+	}
+
+	// Other values are written as encoding/json would write them
+	raw, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("schema can't be written as JSON, error: %s", err))
+	}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	var result interface{}
+	if err := d.Decode(&result); err != nil {
+		panic(fmt.Sprintf("internal error -- failed to decode JSON, error: %s", err))
+	}
+	return canonicalValue(result)
+}
+
+// canonicalFloat returns f in shortest form, whole numbers that fit in an
+// int64 are written like integers.
+func canonicalFloat(f float64) interface{} {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f // fails when encoded
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		return json.Number(strconv.FormatInt(int64(f), 10))
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// sortCanonical sorts the list of canonical values by their JSON encoding.
+func sortCanonical(list []interface{}) {
+	type item struct {
+		key   string
+		value interface{}
+	}
+	items := make([]item, len(list))
+	for i, v := range list {
+		raw, _ := json.Marshal(v)
+		items[i] = item{key: string(raw), value: v}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].key < items[j].key })
+	for i, it := range items {
+		list[i] = it.value
+	}
+}
@@ -0,0 +1,70 @@
+package schematypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	s := Object{
+		Properties: Properties{
+			"size": Integer{Minimum: 1, Maximum: 10},
+			"mode": StringEnum{Options: []string{"slow", "fast"}},
+			"wait": Duration{},
+			"path": String{Pattern: "^/<a>&"},
+		},
+		Required: []string{"size", "mode"},
+	}
+	assert(string(Canonicalize(s)) == `{"additionalProperties":false,"properties":{`+
+		`"mode":{"enum":["fast","slow"],"type":"string"},`+
+		`"path":{"pattern":"^/<a>&","type":"string"},`+
+		`"size":{"maximum":10,"minimum":1,"type":"integer"},`+
+		`"wait":{"pattern":"`+jsonEscape(durationRegexp.String())+`","type":["integer","string"]}},`+
+		`"required":["mode","size"],"type":"object"}`,
+		"Unexpected canonical form: ", string(Canonicalize(s)))
+
+	// Equal schemas constructed differently have the same canonical form
+	raw, err := NewSchema(`{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["mode", "size"],
+		"properties": {
+			"size": {"type": "integer", "minimum": 1.0, "maximum": 1e1},
+			"mode": {"type": "string", "enum": ["fast", "slow"]},
+			"wait": {"type": ["string", "integer"], "pattern": ` + string(mustMarshal(durationRegexp.String())) + `},
+			"path": {"type": "string", "pattern": "^/<a>&"}
+		}
+	}`)
+	nilOrPanic(err, "Expected NewSchema to succeed")
+	assert(string(Canonicalize(raw)) == string(Canonicalize(s)),
+		"Expected equal canonical forms, got: ", string(Canonicalize(raw)))
+	assert(Fingerprint(raw) == Fingerprint(s), "Expected equal fingerprints")
+
+	// Numbers are written in shortest form
+	assert(string(Canonicalize(Number{Minimum: 0.5, Maximum: 1e300, MultipleOf: 0.25})) ==
+		`{"maximum":1e+300,"minimum":0.5,"multipleOf":0.25,"type":"number"}`,
+		"Unexpected canonical form: ", string(Canonicalize(Number{Minimum: 0.5, Maximum: 1e300, MultipleOf: 0.25})))
+}
+
+func TestFingerprint(t *testing.T) {
+	f := Fingerprint(Integer{Minimum: 1, Maximum: 10})
+	assert(len(f) == 64, "Expected a hex encoded SHA-256 hash, got: ", f)
+	assert(f == Fingerprint(Integer{Minimum: 1, Maximum: 10}), "Expected a stable fingerprint")
+	assert(f != Fingerprint(Integer{Minimum: 1, Maximum: 11}), "Expected fingerprints to differ")
+	assert(Fingerprint(StringEnum{Options: []string{"a", "b"}}) ==
+		Fingerprint(StringEnum{Options: []string{"b", "a"}}), "Expected enum order to be ignored")
+	assert(Fingerprint(AnyOf{String{}, Integer{}}) != Fingerprint(AnyOf{Integer{}, String{}}),
+		"Expected order of anyOf to be kept")
+}
+
+func mustMarshal(v interface{}) []byte {
+	raw, err := json.Marshal(v)
+	nilOrPanic(err, "Internal test error")
+	return raw
+}
+
+// jsonEscape returns s as a JSON string without quotes and HTML escaping
+func jsonEscape(s string) string {
+	raw := mustMarshal(s)
+	return string(raw[1 : len(raw)-1])
+}
@@ -0,0 +1,239 @@
+package schematypes
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CheckType checks that values matching schema can be mapped into the type t
+// with Map, without any data to map. Here t is the type that the target given
+// to Map points to, such as reflect.TypeOf(Config{}).
+//
+// This returns nil if the types match, otherwise it returns a TypeCheckError
+// with every mismatch found, such as properties without a struct field or
+// integer types too narrow for the bounds of an Integer. This is useful for
+// catching mistakes in init() or tests, rather than when mapping data.
+//
+// Types implementing Mapper, json.Unmarshaler or encoding.TextUnmarshaler are
+// assumed to match, as they handle the values themselves, except time.Time,
+// which only DateTime maps into.
+func CheckType(schema Schema, t reflect.Type) error {
+	c := &typeCheck{}
+	c.check(schema, t, "")
+	if len(c.mismatches) == 0 {
+		return nil
+	}
+	return &TypeCheckError{Mismatches: c.mismatches}
+}
+
+// A typeCheck holds the mismatches found by CheckType.
+type typeCheck struct {
+	mismatches []*TypeMismatchError
+}
+
+// mismatch adds a mismatch for mapping s into t at path.
+func (c *typeCheck) mismatch(s Schema, t reflect.Type, path, reason string, args ...interface{}) {
+	c.mismatches = append(c.mismatches, &TypeMismatchError{
+		Path:       path,
+		SchemaType: reflect.TypeOf(s).Name(),
+		TargetType: t,
+		Reason:     fmt.Sprintf(reason, args...),
+	})
+}
+
+// check adds the mismatches for mapping s into t at path.
+func (c *typeCheck) check(s Schema, t reflect.Type, path string) {
+	if hasHook(t) {
+		return
+	}
+
+	switch s := s.(type) {
+	case Object:
+		switch {
+		case t == typeOfEmptyInterface:
+		case t.Kind() == reflect.Struct:
+			c.checkStruct(s, t, path)
+		case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+			for _, key := range sortedKeys(s.Properties) {
+				c.checkMapValue(s.Properties[key], t.Elem(), path+formatKeyPath(key))
+			}
+		default:
+			c.mismatch(s, t, path, "expected a struct, a map with string keys or interface{}")
+		}
+	case Array:
+		if t.Kind() != reflect.Slice {
+			c.mismatch(s, t, path, "expected a slice")
+			return
+		}
+		c.check(s.Items, t.Elem(), path+"[]")
+	case Map:
+		if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+			c.mismatch(s, t, path, "expected a map with string keys")
+			return
+		}
+		c.checkMapValue(s.Values, t.Elem(), path+"[]")
+	case String, StringEnum:
+		if t.Kind() != reflect.String {
+			c.mismatch(s, t, path, "expected a string type")
+		}
+	case Boolean:
+		if t.Kind() != reflect.Bool {
+			c.mismatch(s, t, path, "expected a bool type")
+		}
+	case Integer:
+		c.checkInteger(s, s.Minimum, s.Maximum, t, path)
+	case IntegerEnum:
+		min, max := s.bounds()
+		c.checkInteger(s, min, max, t, path)
+	case Number:
+		c.checkNumber(s, t, path)
+	case URI:
+		if t.Kind() != reflect.String && t != typeOfURL && t != reflect.PtrTo(typeOfURL) {
+			c.mismatch(s, t, path, "expected a string type, url.URL or *url.URL")
+		}
+	case DateTime:
+		if t.Kind() != reflect.String && t != typeOfTime && t != reflect.PtrTo(typeOfTime) {
+			c.mismatch(s, t, path, "expected a string type, time.Time or *time.Time")
+		}
+	case Duration:
+		if t != typeOfDuration {
+			c.mismatch(s, t, path, "expected time.Duration")
+		}
+	case AnyOf, OneOf, AllOf, schema:
+		if t != typeOfEmptyInterface {
+			c.mismatch(s, t, path, "expected interface{}")
+		}
+	case TaggedUnion:
+		c.checkTaggedUnion(s, t, path)
+	}
+}
+
+// checkStruct adds the mismatches for mapping o into the struct type t.
+func (c *typeCheck) checkStruct(o Object, t reflect.Type, path string) {
+	if f, ok := remainField(t); ok && remainReason(f) != "" {
+		c.mismatch(o, t, path, remainReason(f))
+	}
+
+	fields := structFields(t)
+	seen := make(map[string]bool, len(o.Properties))
+	for _, key := range append(sortedKeys(o.Properties), o.Required...) {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		p := path + formatKeyPath(key)
+		f, ok := lookupField(fields, key)
+		if !ok {
+			c.mismatch(o, t, p, "there is no field for the property")
+			continue
+		}
+
+		// Pointers to unexported embedded structs can't be allocated
+		ft := t
+		for i, x := range f.index {
+			if i > 0 && ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			sf := ft.Field(x)
+			if i < len(f.index)-1 && sf.Type.Kind() == reflect.Ptr && sf.PkgPath != "" {
+				c.mismatch(o, t, p, "the embedded %v can't be allocated", sf.Type)
+			}
+			ft = sf.Type
+		}
+
+		s := o.Properties[key]
+		if s == nil {
+			continue // required, but without a schema
+		}
+		ft = f.typ
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft == typeOfEmptyInterface {
+			continue
+		}
+		// Values with the string option may be parsed from their JSON string
+		if f.quoted && ft.Kind() != reflect.String {
+			if _, ok := s.(String); ok {
+				continue
+			}
+		}
+		c.check(s, ft, p)
+	}
+}
+
+// checkMapValue adds the mismatches for mapping s into values of a map with
+// the value type t.
+func (c *typeCheck) checkMapValue(s Schema, t reflect.Type, path string) {
+	if s == nil || t == typeOfEmptyInterface {
+		return
+	}
+	// Pointer values are not allocated, so they are checked as they are
+	c.check(s, t, path)
+}
+
+// checkInteger adds the mismatches for mapping integers from min to max into
+// the integer type t.
+func (c *typeCheck) checkInteger(s Schema, min, max int64, t reflect.Type, path string) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !integerFits(t.Kind(), min, max) {
+			c.mismatch(s, t, path, "%v can't hold integers from %d to %d", t.Kind(), min, max)
+		}
+	default:
+		c.mismatch(s, t, path, "expected an integer type")
+	}
+}
+
+// checkNumber adds the mismatches for mapping n into t, which may be a
+// pointer.
+func (c *typeCheck) checkNumber(n Number, t reflect.Type, path string) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Float64, reflect.Float32:
+		// Numbers that overflows float32 are rejected by Map for each value
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !n.integral() {
+			c.mismatch(n, t, path, "expected a float type, as MultipleOf isn't a whole number")
+		} else if !integerFits(t.Kind(), clampInt64(n.Minimum), clampInt64(n.Maximum)) {
+			c.mismatch(n, t, path, "%v can't hold numbers from %g to %g", t.Kind(), n.Minimum, n.Maximum)
+		}
+	default:
+		c.mismatch(n, t, path, "expected a float or integer type")
+	}
+}
+
+// checkTaggedUnion adds the mismatches for mapping u into t.
+func (c *typeCheck) checkTaggedUnion(u TaggedUnion, t reflect.Type, path string) {
+	switch t.Kind() {
+	case reflect.Interface:
+		// Each variant must have a type implementing the interface
+		for _, tag := range u.tags() {
+			variant := u.Variants[tag]
+			if variant.Type == nil {
+				if t != typeOfEmptyInterface {
+					c.mismatch(u, t, path, "variant %q has no Type", tag)
+				}
+				continue
+			}
+			if !variant.Type.AssignableTo(t) && !reflect.PtrTo(variant.Type).AssignableTo(t) {
+				c.mismatch(u, t, path, "the Type %v of variant %q doesn't implement it", variant.Type, tag)
+			}
+			c.check(variant.Schema, variant.Type, path)
+		}
+	case reflect.Map:
+	default:
+		for _, tag := range u.tags() {
+			if variant := u.Variants[tag]; variant.Type == t {
+				c.check(variant.Schema, t, path)
+				return
+			}
+		}
+		c.mismatch(u, t, path, "expected an interface, a map or the Type of a variant")
+	}
+}
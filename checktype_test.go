@@ -0,0 +1,93 @@
+package schematypes
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type checkTypeTestStruct struct {
+	Name    string            `json:"name"`
+	Level   int8              `json:"level"`
+	Tags    string            `json:"tags"`
+	Limits  map[string]uint16 `json:"limits"`
+	Created *time.Time        `json:"created"`
+	Home    url.URL           `json:"home"`
+	Owner   struct {
+		ID int `json:"id"`
+	} `json:"owner"`
+}
+
+func TestCheckType(t *testing.T) {
+	s := Object{
+		Properties: Properties{
+			"name":    String{},
+			"level":   Integer{Minimum: 0, Maximum: 1000},
+			"tags":    Array{Items: String{}},
+			"limits":  Map{Values: IntegerEnum{Options: []int{-1, 1}}},
+			"created": DateTime{},
+			"home":    URI{},
+			"owner":   Object{Properties: Properties{"id": String{}}},
+			"timeout": Duration{},
+		},
+	}
+	err := CheckType(s, reflect.TypeOf(checkTypeTestStruct{}))
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+	e, ok := err.(*TypeCheckError)
+	assert(ok, "Expected a TypeCheckError, got: ", err)
+
+	expected := []struct {
+		path, schemaType, reason string
+	}{
+		{".level", "Integer", "int8 can't hold integers from 0 to 1000"},
+		{".limits[]", "IntegerEnum", "uint16 can't hold integers from -1 to 1"},
+		{".owner.id", "String", "expected a string type"},
+		{".tags", "Array", "expected a slice"},
+		{".timeout", "Object", "there is no field for the property"},
+	}
+	assert(len(e.Mismatches) == len(expected), "Unexpected mismatches: ", e)
+	for i, m := range e.Mismatches {
+		assert(m.Path == expected[i].path, "Unexpected path: ", m.Path)
+		assert(m.SchemaType == expected[i].schemaType, "Unexpected schema type: ", m.SchemaType)
+		assert(m.Reason == expected[i].reason, "Unexpected reason: ", m.Reason)
+	}
+
+	// CheckType agrees with Map
+	nilOrPanic(CheckType(planTestSchema, reflect.TypeOf(planTestStruct{})), "Expected types to match")
+	nilOrPanic(CheckType(jsonTestSchema, reflect.TypeOf(jsonTestStruct{})), "Expected types to match")
+	nilOrPanic(CheckType(generateTestSchema, reflect.TypeOf(generateTestStruct{})), "Expected types to match")
+	var f *float64
+	var i uint8
+	nilOrPanic(CheckType(Number{Minimum: 0, Maximum: 1}, reflect.TypeOf(f)), "Expected types to match")
+	nilOrPanic(CheckType(Number{Minimum: 0, Maximum: 200, MultipleOf: 1}, reflect.TypeOf(i)),
+		"Expected types to match")
+	err = CheckType(Number{Minimum: 0, Maximum: 200}, reflect.TypeOf(i))
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+	var id mapperTestID
+	nilOrPanic(CheckType(Integer{}, reflect.TypeOf(id)), "Expected Mapper types to match")
+}
+
+func TestCheckTypeFloat32(t *testing.T) {
+	// Numbers are checked for overflow by Map, so any bounds are accepted
+	var target struct {
+		Ratio float32 `json:"ratio"`
+	}
+	s := Object{Properties: Properties{
+		"ratio": Number{Minimum: -math.MaxFloat64, Maximum: math.MaxFloat64},
+	}}
+	nilOrPanic(CheckType(s, reflect.TypeOf(target)), "Expected float32 to match")
+}
+
+func TestCheckTypeTaggedUnion(t *testing.T) {
+	s := taggedUnionTestSchema
+	nilOrPanic(CheckType(s, reflect.TypeOf((*taggedUnionTestEngine)(nil)).Elem()), "Expected types to match")
+	nilOrPanic(CheckType(s, reflect.TypeOf(taggedUnionTestDocker{})), "Expected types to match")
+
+	err := CheckType(s, reflect.TypeOf((*error)(nil)).Elem())
+	e, ok := err.(*TypeCheckError)
+	assert(ok, "Expected a TypeCheckError, got: ", err)
+	assert(len(e.Mismatches) == 2, "Expected a mismatch for each variant, got: ", e)
+}
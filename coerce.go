@@ -0,0 +1,138 @@
+package schematypes
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A Coercion is a string value converted by Coerce.
+type Coercion struct {
+	// Path to the value, on the same form as ValidationIssue.Path() without a
+	// root name.
+	Path string
+	// SchemaType is the name of the schema type, such as "Integer".
+	SchemaType string
+	// Value is the string that was converted.
+	Value string
+	// Result is the value the string was converted to.
+	Result interface{}
+}
+
+// Coerce returns data with strings converted to the types declared by schema,
+// for data from sources where all values are strings, such as environment
+// variables, query strings and command line flags. Strings are converted for:
+//
+//   - Integer and IntegerEnum, such as "42", converted to int64,
+//   - Number, such as "1.5", converted to float64,
+//   - Boolean, "true", "yes", "on" and "1", or "false", "no", "off" and "0",
+//     ignoring case, converted to bool, and
+//   - Array, a comma-separated list, such as "a, b", converted to
+//     []interface{} with each item converted for the items schema.
+//
+// Values in objects, arrays and maps are converted for their sub-schemas, the
+// variant of a TaggedUnion is converted for the variant schema. Strings that
+// can't be converted are left as they are, so validation reports them.
+//
+// Coerce returns each conversion made, so they can be audited, and doesn't
+// modify data. Use Options.Coerce to coerce data before validation.
+func Coerce(schema Schema, data interface{}) (interface{}, []Coercion) {
+	var coercions []Coercion
+	result := coerce(schema, data, "", func(c Coercion) {
+		coercions = append(coercions, c)
+	})
+	return result, coercions
+}
+
+// coerce returns data coerced for s at path, calling record for each
+// conversion.
+func coerce(s Schema, data interface{}, path string, record func(Coercion)) interface{} {
+	switch s := s.(type) {
+	case Object:
+		value, ok := data.(map[string]interface{})
+		if !ok {
+			return data
+		}
+		result := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			if ps, ok := s.Properties[key]; ok && ps != nil {
+				v = coerce(ps, v, path+formatKeyPath(key), record)
+			}
+			result[key] = v
+		}
+		return result
+	case Map:
+		value, ok := data.(map[string]interface{})
+		if !ok {
+			return data
+		}
+		result := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			result[key] = coerce(s.Values, v, path+formatKeyPath(key), record)
+		}
+		return result
+	case TaggedUnion:
+		value, ok := data.(map[string]interface{})
+		if !ok {
+			return data
+		}
+		if tag, ok := value[s.Discriminator].(string); ok {
+			if _, ok := s.Variants[tag]; ok {
+				return coerce(s.variantSchema(tag), data, path, record)
+			}
+		}
+		return data
+	case Array:
+		var items []interface{}
+		switch value := data.(type) {
+		case []interface{}:
+			items = value
+		case string:
+			items = []interface{}{}
+			if value != "" {
+				for _, item := range strings.Split(value, ",") {
+					items = append(items, strings.TrimSpace(item))
+				}
+			}
+			record(Coercion{Path: path, SchemaType: "Array", Value: value, Result: items})
+		default:
+			return data
+		}
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			result[i] = coerce(s.Items, item, path+"["+strconv.Itoa(i)+"]", record)
+		}
+		return result
+	}
+
+	// Convert strings for schemas of other types
+	value, ok := data.(string)
+	if !ok {
+		return data
+	}
+	var result interface{}
+	text := strings.TrimSpace(value)
+	switch s.(type) {
+	case Integer, IntegerEnum:
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			result = i
+		}
+	case Number:
+		if f, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			result = f
+		}
+	case Boolean:
+		switch strings.ToLower(text) {
+		case "true", "yes", "on", "1":
+			result = true
+		case "false", "no", "off", "0":
+			result = false
+		}
+	}
+	if result == nil {
+		return data
+	}
+	record(Coercion{Path: path, SchemaType: reflect.TypeOf(s).Name(), Value: value, Result: result})
+	return result
+}
@@ -0,0 +1,125 @@
+package schematypes
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+var coerceTestSchema = Object{
+	Properties: Properties{
+		"port":    Integer{Minimum: 1, Maximum: 65535},
+		"ratio":   Number{Minimum: 0, Maximum: 1},
+		"debug":   Boolean{},
+		"name":    String{},
+		"tags":    Array{Items: String{}},
+		"retries": Array{Items: IntegerEnum{Options: []int{1, 2, 3}}},
+		"limits":  Map{Values: Integer{Minimum: 0, Maximum: 10}},
+	},
+}
+
+func TestCoerce(t *testing.T) {
+	data := map[string]interface{}{
+		"port":    "8080",
+		"ratio":   " 0.5",
+		"debug":   "Yes",
+		"name":    "42",
+		"tags":    "a, b,c",
+		"retries": "1,3",
+		"limits":  map[string]interface{}{"cpu": "2"},
+	}
+	result, coercions := Coerce(coerceTestSchema, data)
+	assertJSON(result, `{
+		"port": 8080,
+		"ratio": 0.5,
+		"debug": true,
+		"name": "42",
+		"tags": ["a", "b", "c"],
+		"retries": [1, 3],
+		"limits": {"cpu": 2}
+	}`, "Unexpected result")
+	assert(data["port"] == "8080", "Expected data to be unchanged")
+
+	paths := map[string]Coercion{}
+	for _, c := range coercions {
+		paths[c.Path] = c
+	}
+	assert(len(coercions) == 8, "Unexpected coercions: ", coercions)
+	c := paths[".port"]
+	assert(c.SchemaType == "Integer" && c.Value == "8080" && c.Result == int64(8080),
+		"Unexpected coercion: ", c)
+	c = paths[".retries[1]"]
+	assert(c.SchemaType == "IntegerEnum" && c.Result == int64(3), "Unexpected coercion: ", c)
+	c = paths[".tags"]
+	assert(c.SchemaType == "Array" && c.Value == "a, b,c", "Unexpected coercion: ", c)
+	_, ok := paths[".limits.cpu"]
+	assert(ok, "Expected a coercion for limits")
+
+	// Values that can't be converted are left as they are
+	result, coercions = Coerce(coerceTestSchema, map[string]interface{}{
+		"port":  "http",
+		"debug": "maybe",
+		"ratio": "NaN",
+		"tags":  []interface{}{"a"},
+	})
+	assertJSON(result, `{"port": "http", "debug": "maybe", "ratio": "NaN", "tags": ["a"]}`,
+		"Unexpected result")
+	assert(len(coercions) == 0, "Unexpected coercions: ", coercions)
+
+	// Empty lists are empty arrays
+	result, _ = Coerce(Array{Items: Integer{}}, "")
+	assertJSON(result, `[]`, "Unexpected result")
+}
+
+func TestCoerceOptions(t *testing.T) {
+	data := map[string]interface{}{"port": "80", "debug": "off"}
+
+	// Strict mode is the default
+	err := ValidateWithOptions(coerceTestSchema, data, Options{})
+	assert(err != nil, "Expected strings to be invalid without coercion")
+
+	var coercions []Coercion
+	options := Options{Coerce: true, OnCoerce: func(c Coercion) {
+		coercions = append(coercions, c)
+	}}
+	nilOrPanic(ValidateWithOptions(coerceTestSchema, data, options), "Expected coerced data to be valid")
+	assert(len(coercions) == 2, "Unexpected coercions: ", coercions)
+
+	target := map[string]interface{}{}
+	nilOrPanic(MapContext(context.Background(), coerceTestSchema, data, &target, options),
+		"Expected MapContext to succeed")
+	assert(target["port"] == int64(80) && target["debug"] == false, "Unexpected target: ", target)
+
+	// Coerced values are validated
+	err = ValidateWithOptions(coerceTestSchema, map[string]interface{}{"port": "0"}, Options{Coerce: true})
+	e, ok := err.(*ValidationError)
+	assert(ok, "Expected a ValidationError, got: ", err)
+	assert(e.issues[0].Keyword() == "minimum", "Unexpected issue: ", e)
+}
+
+func TestCoerceLimits(t *testing.T) {
+	// Limits are checked before strings are split into arrays
+	tags := strings.Repeat("a,", 1<<20)
+	coerced := false
+	options := Options{Coerce: true, MaxStringLength: 1024, OnCoerce: func(Coercion) {
+		coerced = true
+	}}
+	err := ValidateWithOptions(coerceTestSchema, map[string]interface{}{"tags": tags}, options)
+	e, ok := err.(*ValidationError)
+	assert(ok && len(e.issues) == 1, "Expected a ValidationError, got: ", err)
+	assert(e.issues[0].LimitExceeded(), "Expected the string length limit to be exceeded: ", e)
+	assert(e.issues[0].Path() == ".tags", "Unexpected path: ", e.issues[0].Path())
+	assert(!coerced, "Expected no coercion when the limits are exceeded")
+
+	// Arrays from coercion are checked too
+	options = Options{Coerce: true, MaxArrayLength: 2}
+	err = ValidateWithOptions(coerceTestSchema, map[string]interface{}{"tags": "a, b, c"}, options)
+	e, ok = err.(*ValidationError)
+	assert(ok && e.issues[0].LimitExceeded(), "Expected the array length limit to be exceeded, got: ", err)
+
+	// Cancellation is checked before coercion
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = MapContext(ctx, coerceTestSchema, map[string]interface{}{"port": "80"}, &map[string]interface{}{}, options)
+	assert(err == context.Canceled, "Expected context.Canceled, got: ", err)
+}
@@ -1,6 +1,10 @@
 package schematypes
 
-import "reflect"
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
 
 // An AnyOf instance represents the anyOf JSON schema construction.
 type AnyOf []Schema
@@ -23,16 +27,31 @@ func (s AnyOf) Schema() map[string]interface{} {
 // Validate the given data, this will return nil if data satisfies this schema.
 // Otherwise, Validate(data) returns a ValidationError instance.
 func (s AnyOf) Validate(data interface{}) error {
-	for _, schema := range s {
-		if schema.Validate(data) == nil {
+	return s.validate(&validation{}, data)
+}
+
+func (s AnyOf) validate(v *validation, data interface{}) error {
+	errs := make([]error, len(s))
+	for i, schema := range s {
+		warnings := len(v.warnings)
+		if errs[i] = v.validateAt(schema, data, subSchemaLocation("anyOf", i)); errs[i] == nil {
 			return nil
 		}
+		v.warnings = v.warnings[:warnings] // discard warnings from failed options
 	}
-	return singleIssue("", "None of the anyOf options at {path} was satisfied")
+	return noneSatisfied("anyOf", s, data, errs)
 }
 
 // Map takes data, validates and maps it into the target reference.
 func (s AnyOf) Map(data, target interface{}) error {
+	if err := s.Validate(data); err != nil {
+		return err
+	}
+	return s.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (s AnyOf) mapValidated(data, target interface{}) error {
 	return mapToEmptyInterface(s, data, target)
 }
 
@@ -48,23 +67,41 @@ func (s OneOf) Schema() map[string]interface{} {
 // Validate the given data, this will return nil if data satisfies this schema.
 // Otherwise, Validate(data) returns a ValidationError instance.
 func (s OneOf) Validate(data interface{}) error {
-	satisfied := 0
-	for _, schema := range s {
-		if schema.Validate(data) == nil {
-			satisfied++
+	return s.validate(&validation{}, data)
+}
+
+func (s OneOf) validate(v *validation, data interface{}) error {
+	errs := make([]error, len(s))
+	satisfied := []int{}
+	for i, schema := range s {
+		warnings := len(v.warnings)
+		if errs[i] = v.validateAt(schema, data, subSchemaLocation("oneOf", i)); errs[i] == nil {
+			satisfied = append(satisfied, i)
+		} else {
+			v.warnings = v.warnings[:warnings] // discard warnings from failed options
 		}
 	}
-	if satisfied == 0 {
-		return singleIssue("", "None of the oneOf options at {path} was satisfied")
+	if len(satisfied) == 0 {
+		return noneSatisfied("oneOf", s, data, errs)
 	}
-	if satisfied > 1 {
-		return singleIssue("", "More than one of the oneOf options at {path} was satisfied")
+	if len(satisfied) > 1 {
+		return singleIssue("oneOf",
+			"More than one of the oneOf options at {path} was satisfied, options %v matched",
+			satisfied)
 	}
 	return nil
 }
 
 // Map takes data, validates and maps it into the target reference.
 func (s OneOf) Map(data, target interface{}) error {
+	if err := s.Validate(data); err != nil {
+		return err
+	}
+	return s.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (s OneOf) mapValidated(data, target interface{}) error {
 	return mapToEmptyInterface(s, data, target)
 }
 
@@ -79,34 +116,205 @@ func (s AllOf) Schema() map[string]interface{} {
 
 // Validate the given data, this will return nil if data satisfies this schema.
 // Otherwise, Validate(data) returns a ValidationError instance.
+//
+// Issues from all the sub-schemas are returned, with KeywordLocation() of each
+// issue identifying the sub-schema it came from. Issues with the same path and
+// keyword are only reported for the first sub-schema, issues for missing
+// properties are only the same if the same property is missing.
 func (s AllOf) Validate(data interface{}) error {
-	for _, schema := range s {
-		if err := schema.Validate(data); err != nil {
-			return err
+	return s.validate(&validation{}, data)
+}
+
+func (s AllOf) validate(v *validation, data interface{}) error {
+	e := &ValidationError{}
+	for i, schema := range s {
+		if v.full(e) {
+			break
+		}
+		l := subSchemaLocation("allOf", i)
+		e.addIssuesAt(v.validateAt(schema, data, l), l)
+	}
+
+	// Remove duplicate issues
+	seen := make(map[[3]string]bool)
+	issues := []ValidationIssue{}
+	for _, issue := range e.issues {
+		key := [3]string{issue.InstanceLocation(), issue.Keyword(), issue.property}
+		if !seen[key] {
+			seen[key] = true
+			issues = append(issues, issue)
 		}
 	}
+	e.issues = issues
+
+	if len(e.issues) > 0 {
+		e.Sort()
+		return e
+	}
 	return nil
 }
 
 // Map takes data, validates and maps it into the target reference.
 func (s AllOf) Map(data, target interface{}) error {
+	if err := s.Validate(data); err != nil {
+		return err
+	}
+	return s.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (s AllOf) mapValidated(data, target interface{}) error {
 	return mapToEmptyInterface(s, data, target)
 }
 
+// noneSatisfied returns a ValidationError explaining that none of the schemas
+// given as options for keyword was satisfied, errs is the error returned from
+// validating data against each of the schemas.
+func noneSatisfied(keyword string, schemas []Schema, data interface{}, errs []error) error {
+	options := &ValidationError{}
+	for i, err := range errs {
+		options.addIssuesAt(err, subSchemaLocation(keyword, i))
+	}
+	best := bestMatch(schemas, data, errs)
+	return &ValidationError{issues: []ValidationIssue{{
+		message: fmt.Sprintf(
+			"None of the %s options at {path} was satisfied, closest match is option %d",
+			keyword, best),
+		locations: []location{{schema: []string{keyword}}},
+		causes:    options.issues,
+		bestMatch: best,
+	}}}
+}
+
+// bestMatch returns the index of the schema that is the closest match for
+// data. This is the first object schema with a property restricted to a
+// single string that data has. Otherwise, it is the schema with the fewest
+// issues, not counting schemas for which data has the wrong type.
+func bestMatch(schemas []Schema, data interface{}, errs []error) int {
+	for i, schema := range schemas {
+		if discriminates(schema, data) {
+			return i
+		}
+	}
+
+	best, fewest := 0, math.MaxInt32
+	for i, err := range errs {
+		issues := math.MaxInt32
+		if e, ok := err.(*ValidationError); ok && !isTypeMismatch(e) {
+			issues = len(e.issues)
+		}
+		if issues < fewest {
+			best, fewest = i, issues
+		}
+	}
+	return best
+}
+
+// discriminates returns true, if schema is an object schema with a property
+// restricted to a single string which data has.
+func discriminates(schema Schema, data interface{}) bool {
+	obj, ok := schema.(Object)
+	if !ok {
+		return false
+	}
+	value, ok := data.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key, s := range obj.Properties {
+		enum, ok := s.(StringEnum)
+		if !ok || len(enum.Options) != 1 {
+			continue
+		}
+		if v, ok := value[key].(string); ok && v == enum.Options[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// isTypeMismatch returns true, if e is a single issue saying the value had the
+// wrong type.
+func isTypeMismatch(e *ValidationError) bool {
+	return len(e.issues) == 1 && e.issues[0].Keyword() == "type" &&
+		e.issues[0].InstanceLocation() == ""
+}
+
+func (s AnyOf) unmap(value reflect.Value) (interface{}, error) {
+	return unmapOption(s, s, value)
+}
+
+func (s OneOf) unmap(value reflect.Value) (interface{}, error) {
+	return unmapOption(s, s, value)
+}
+
+// unmapOption returns value unmapped with the first of the options that it
+// satisfies. If value doesn't satisfy any option, the data from the first
+// option it can be unmapped with is returned, as validation will fail anyway.
+func unmapOption(s Schema, options []Schema, value reflect.Value) (interface{}, error) {
+	var result interface{}
+	found := false
+	for _, option := range options {
+		data, err := unmapValue(option, value)
+		if err != nil {
+			continue
+		}
+		if option.Validate(data) == nil {
+			return data, nil
+		}
+		if !found {
+			result, found = data, true
+		}
+	}
+	if !found {
+		return nil, unmapMismatch(s, value)
+	}
+	return result, nil
+}
+
+// unmap merges the data from unmapping value with each schema, when value is
+// unmapped to objects. Otherwise, value must be unmapped to the same data
+// with all schemas.
+func (s AllOf) unmap(value reflect.Value) (interface{}, error) {
+	var result interface{}
+	for i, schema := range s {
+		data, err := unmapValue(schema, value)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			result = data
+			continue
+		}
+		obj, ok := result.(map[string]interface{})
+		props, isObject := data.(map[string]interface{})
+		if ok && isObject {
+			for key, v := range props {
+				obj[key] = v
+			}
+		} else if !jsonEqual(result, data) {
+			return nil, unmapMismatch(s, value)
+		}
+	}
+	return result, nil
+}
+
+// mapToEmptyInterface maps data, which must be valid against s, into target,
+// which must be a pointer to an empty interface.
 func mapToEmptyInterface(s Schema, data, target interface{}) error {
-	if err := s.Validate(data); err != nil {
+	if ok, err := mapHook(s, data, target); ok {
 		return err
 	}
 
 	// Ensure that we have a pointer as input
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
 	}
 	val := ptr.Elem()
 
 	if val.Type() != typeOfEmptyInterface {
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
 	}
 
 	val.Set(reflect.ValueOf(data))
@@ -1,6 +1,9 @@
 package schematypes
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+)
 
 var any interface{}
 
@@ -153,3 +156,118 @@ func TestAllOf(t *testing.T) {
 		},
 	}.Test(t)
 }
+
+func TestAnyOfBestMatch(t *testing.T) {
+	s := AnyOf{
+		String{},
+		Integer{Minimum: 0, Maximum: 10},
+		Integer{Minimum: 0, Maximum: 20},
+	}
+	e, ok := s.Validate(float64(30)).(*ValidationError)
+	assert(ok, "Expected a ValidationError")
+	assert(len(e.issues) == 1, "Expected a single issue")
+	issue := e.Issues("")[0]
+	assert(issue.Keyword() == "anyOf", "Expected anyOf keyword")
+	assert(len(issue.Causes()) == 3, "Expected a cause for each option")
+	best := issue.BestMatch()
+	assert(len(best) == 1, "Expected a single cause from the best match")
+	assert(best[0].KeywordLocation() == "/anyOf/1/maximum",
+		"Unexpected best match: ", best[0].KeywordLocation())
+	assert(issue.String() == "None of the anyOf options at root was satisfied, "+
+		"closest match is option 1 (Integer 30 at root is larger than maximum 10)",
+		"Unexpected message: ", issue.String())
+}
+
+func TestAnyOfDiscriminator(t *testing.T) {
+	s := AnyOf{
+		Object{
+			Properties: Properties{
+				"type":  StringEnum{Options: []string{"docker"}},
+				"image": String{},
+			},
+			Required: []string{"type", "image"},
+		},
+		Object{
+			Properties: Properties{
+				"type":    StringEnum{Options: []string{"native"}},
+				"command": String{},
+				"user":    String{},
+			},
+			Required: []string{"type", "command", "user"},
+		},
+	}
+	var data interface{}
+	nilOrPanic(json.Unmarshal([]byte(`{"type": "native"}`), &data), "Bad JSON")
+	e := s.Validate(data).(*ValidationError)
+	issue := e.Issues("config")[0]
+	best := issue.BestMatch()
+	assert(len(best) == 2, "Expected two issues from the native option")
+	for _, cause := range best {
+		assert(cause.Keyword() == "required", "Expected required issues")
+		assert(cause.Path() == "config.command" || cause.Path() == "config.user",
+			"Unexpected path: ", cause.Path())
+	}
+}
+
+func TestAnyOfOutput(t *testing.T) {
+	s := Object{
+		Properties: Properties{
+			"value": AnyOf{String{}, Integer{Minimum: 0, Maximum: 10}},
+		},
+	}
+	e := s.Validate(map[string]interface{}{"value": float64(30)}).(*ValidationError)
+	units := e.Output(OutputBasic).Errors
+	assert(len(units) == 3, "Expected the anyOf issue and 2 causes")
+	assert(units[0].KeywordLocation == "/properties/value/anyOf", "Expected anyOf first")
+	assert(units[1].KeywordLocation == "/properties/value/anyOf/0/type", "Unexpected unit")
+	assert(units[2].KeywordLocation == "/properties/value/anyOf/1/maximum", "Unexpected unit")
+
+	u := e.Output(OutputDetailed)
+	assert(len(u.Errors) == 1, "Expected a single unit")
+	assert(u.Errors[0].KeywordLocation == "/properties/value/anyOf", "Expected anyOf unit")
+	assert(len(u.Errors[0].Errors) == 2, "Expected a unit for each option")
+}
+
+func TestOneOfMultipleMatches(t *testing.T) {
+	s := OneOf{
+		Integer{Minimum: 0, Maximum: 10},
+		String{},
+		Number{Minimum: 0, Maximum: 10},
+	}
+	e := s.Validate(float64(5)).(*ValidationError)
+	assert(e.Issues("")[0].String() == "More than one of the oneOf options at "+
+		"root was satisfied, options [0 2] matched", "Unexpected message: ", e)
+}
+
+func TestAllOfIssues(t *testing.T) {
+	s := AllOf{
+		Object{
+			Properties: Properties{
+				"image": String{},
+				"name":  String{MaximumLength: 3},
+			},
+			AdditionalProperties: true,
+			Required:             []string{"image"},
+		},
+		Object{
+			Properties: Properties{
+				"name":  String{MaximumLength: 5},
+				"count": Integer{Minimum: 0, Maximum: 10},
+			},
+			AdditionalProperties: true,
+			Required:             []string{"image", "count"},
+		},
+	}
+	var data interface{}
+	nilOrPanic(json.Unmarshal([]byte(`{"name": "my-name"}`), &data), "Bad JSON")
+	e := s.Validate(data).(*ValidationError)
+	locations := []string{}
+	for _, issue := range e.Issues("") {
+		locations = append(locations, issue.KeywordLocation()+" "+issue.InstanceLocation())
+	}
+	assertJSON(locations, `[
+		"/allOf/1/required ",
+		"/allOf/0/required ",
+		"/allOf/0/properties/name/maxLength /name"
+	]`, "Unexpected issues: ", locations)
+}
@@ -0,0 +1,474 @@
+package schematypes
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// Compatibility classifies a Change between two versions of a schema, in
+// order of severity.
+type Compatibility int
+
+const (
+	// Compatible changes doesn't change which values are valid, such as a new
+	// title or description, so data written for either version of the schema
+	// is valid for both, the change is both backward and forward compatible.
+	Compatible Compatibility = iota
+	// BackwardCompatible changes allows more values, such as a larger maximum,
+	// so data valid for the old schema is valid for the new schema, but data
+	// written for the new schema may not be valid for the old schema.
+	BackwardCompatible
+	// ForwardCompatible changes allows fewer values, such as a removed enum
+	// option, so data written for the new schema is valid for the old schema,
+	// but data stored under the old schema may be rejected by the new schema.
+	ForwardCompatible
+	// Breaking changes are neither backward nor forward compatible, such as a
+	// new required property when additional properties aren't allowed, or a
+	// changed pattern, so data valid for either version may be rejected by the
+	// other.
+	Breaking
+)
+
+func (c Compatibility) String() string {
+	switch c {
+	case Compatible:
+		return "compatible"
+	case BackwardCompatible:
+		return "backward-compatible"
+	case ForwardCompatible:
+		return "forward-compatible"
+	case Breaking:
+		return "breaking"
+	}
+	return fmt.Sprintf("Compatibility(%d)", int(c))
+}
+
+// A Change is a difference between two versions of a schema found by Diff.
+type Change struct {
+	// Path to the values affected, on the same form as TypeMismatchError.Path,
+	// using "[]" for items in arrays and values in maps.
+	Path          string
+	Compatibility Compatibility
+	// Reason describes the change, such as "maximum relaxed from 10 to 20".
+	Reason string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s change at root%s: %s", c.Compatibility, c.Path, c.Reason)
+}
+
+// Diff returns the changes from the old to the new version of a schema, each
+// classified by its Compatibility. This returns nil if the versions are equal.
+//
+// The classification is conservative, a change is Breaking unless it can be
+// determined that all values valid for one version are valid for the other.
+// For example, a changed String pattern or a JSON schema given to NewSchema is
+// always Breaking. Use this to gate schema changes in CI, by failing on any
+// change that is ForwardCompatible or Breaking, if data stored under the old
+// schema must remain valid.
+func Diff(old, new Schema) []Change {
+	d := &schemaDiff{}
+	d.diff(old, new, "")
+	return d.changes
+}
+
+// A schemaDiff holds the changes found by Diff.
+type schemaDiff struct {
+	changes []Change
+}
+
+func (d *schemaDiff) add(c Compatibility, path, reason string, args ...interface{}) {
+	d.changes = append(d.changes, Change{
+		Path:          path,
+		Compatibility: c,
+		Reason:        fmt.Sprintf(reason, args...),
+	})
+}
+
+// widened adds a change at path that allows more values.
+func (d *schemaDiff) widened(path, reason string, args ...interface{}) {
+	d.add(BackwardCompatible, path, reason, args...)
+}
+
+// narrowed adds a change at path that allows fewer values.
+func (d *schemaDiff) narrowed(path, reason string, args ...interface{}) {
+	d.add(ForwardCompatible, path, reason, args...)
+}
+
+// diff adds the changes from the schema from to the schema to at path, a nil
+// schema allows any value.
+func (d *schemaDiff) diff(from, to Schema, path string) {
+	if reflect.DeepEqual(from, to) {
+		return
+	}
+	if from == nil {
+		d.narrowed(path, "schema added")
+		return
+	}
+	if to == nil {
+		d.widened(path, "schema removed")
+		return
+	}
+	if reflect.TypeOf(from) != reflect.TypeOf(to) {
+		d.diffTypes(from, to, path)
+		return
+	}
+
+	title, description := metadataOf(from)
+	if t, s := metadataOf(to); t != title || s != description {
+		d.add(Compatible, path, "title or description changed")
+	}
+
+	switch f := from.(type) {
+	case Object:
+		d.diffObject(f, to.(Object), path)
+	case Array:
+		t := to.(Array)
+		if t.Unique && !f.Unique {
+			d.narrowed(path, "items must now be unique")
+		} else if f.Unique && !t.Unique {
+			d.widened(path, "items no longer need to be unique")
+		}
+		d.diff(f.Items, t.Items, path+"[]")
+	case Map:
+		t := to.(Map)
+		d.lowerBound(path, "minProperties", f.MinimumProperties, t.MinimumProperties)
+		d.upperBound(path, "maxProperties", unlimited(f.MaximumProperties), unlimited(t.MaximumProperties))
+		d.diff(f.Values, t.Values, path+"[]")
+	case Integer:
+		t := to.(Integer)
+		d.lowerBound(path, "minimum", f.Minimum, t.Minimum)
+		d.upperBound(path, "maximum", f.Maximum, t.Maximum)
+	case Number:
+		t := to.(Number)
+		if t.Minimum != f.Minimum {
+			d.bound(t.Minimum < f.Minimum, path, "minimum", f.Minimum, t.Minimum)
+		}
+		if t.Maximum != f.Maximum {
+			d.bound(t.Maximum > f.Maximum, path, "maximum", f.Maximum, t.Maximum)
+		}
+		if t.MultipleOf != f.MultipleOf {
+			// Multiples of a number are also multiples of its divisors
+			switch {
+			case divides(t.MultipleOf, f.MultipleOf):
+				d.bound(true, path, "multipleOf", f.MultipleOf, t.MultipleOf)
+			case divides(f.MultipleOf, t.MultipleOf):
+				d.bound(false, path, "multipleOf", f.MultipleOf, t.MultipleOf)
+			default:
+				d.add(Breaking, path, "multipleOf changed from %g to %g", f.MultipleOf, t.MultipleOf)
+			}
+		}
+	case String:
+		t := to.(String)
+		d.lowerBound(path, "minLength", int64(f.MinimumLength), int64(t.MinimumLength))
+		d.upperBound(path, "maxLength", unlimited(int64(f.MaximumLength)), unlimited(int64(t.MaximumLength)))
+		switch {
+		case f.Pattern == t.Pattern:
+		case f.Pattern == "":
+			d.narrowed(path, "pattern %q added", t.Pattern)
+		case t.Pattern == "":
+			d.widened(path, "pattern %q removed", f.Pattern)
+		default:
+			d.add(Breaking, path, "pattern changed from %q to %q", f.Pattern, t.Pattern)
+		}
+	case StringEnum:
+		t := to.(StringEnum)
+		d.diffOptions(path, f.Options, t.Options, f.Deprecated, t.Deprecated)
+	case IntegerEnum:
+		t := to.(IntegerEnum)
+		d.diffOptions(path, f.Options, t.Options, f.Deprecated, t.Deprecated)
+	case Duration:
+		if t := to.(Duration); f.AllowNegative != t.AllowNegative {
+			d.allowed(path, "negative durations", t.AllowNegative)
+		}
+	case TaggedUnion:
+		d.diffTaggedUnion(f, to.(TaggedUnion), path)
+	case AnyOf:
+		d.diffComposite("anyOf", f, to.(AnyOf), path, true)
+	case OneOf:
+		d.diffComposite("oneOf", f, to.(OneOf), path, false)
+	case AllOf:
+		d.diffComposite("allOf", f, to.(AllOf), path, false)
+	case Boolean, URI, DateTime:
+		// only the title and description can change
+	default:
+		d.add(Breaking, path, "the %s schema changed", reflect.TypeOf(from).Name())
+	}
+}
+
+// diffTypes adds the change from the schema from to the schema to at path,
+// when they are of different types.
+func (d *schemaDiff) diffTypes(from, to Schema, path string) {
+	fromType, toType := reflect.TypeOf(from).Name(), reflect.TypeOf(to).Name()
+	switch {
+	case contains(to, from):
+		d.widened(path, "type changed from %s to %s", fromType, toType)
+	case contains(from, to):
+		d.narrowed(path, "type changed from %s to %s", fromType, toType)
+	default:
+		d.add(Breaking, path, "type changed from %s to %s", fromType, toType)
+	}
+}
+
+// contains returns true, if it can be determined that all values valid for
+// the schema inner are valid for the schema outer, when they are of different
+// types.
+func contains(outer, inner Schema) bool {
+	switch i := inner.(type) {
+	case Integer:
+		// Whole numbers are valid, if 1 is a multiple of o.MultipleOf
+		o, ok := outer.(Number)
+		return ok && o.Minimum <= float64(i.Minimum) && o.Maximum >= float64(i.Maximum) &&
+			divides(o.MultipleOf, 1)
+	case Number:
+		o, ok := outer.(Integer)
+		return ok && divides(1, i.MultipleOf) &&
+			float64(o.Minimum) <= i.Minimum && float64(o.Maximum) >= i.Maximum
+	case IntegerEnum:
+		for _, option := range i.Options {
+			if outer.Validate(float64(option)) != nil {
+				return false
+			}
+		}
+		return true
+	case StringEnum:
+		for _, option := range i.Options {
+			if outer.Validate(option) != nil {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// divides returns true, if multiples of m are also multiples of divisor, where
+// zero means any number.
+func divides(divisor, m float64) bool {
+	if divisor == 0 {
+		return true
+	}
+	q := m / divisor
+	return m != 0 && q == math.Trunc(q)
+}
+
+// diffObject adds the changes from the Object from to the Object to at path.
+func (d *schemaDiff) diffObject(from, to Object, path string) {
+	if from.AdditionalProperties != to.AdditionalProperties {
+		d.allowed(path, "additional properties", to.AdditionalProperties)
+	}
+
+	// Properties and required properties from both versions, sorted
+	keys := append(sortedKeys(from.Properties), sortedKeys(to.Properties)...)
+	keys = append(append(keys, from.Required...), to.Required...)
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		if i > 0 && keys[i-1] == key {
+			continue
+		}
+		p := path + formatKeyPath(key)
+		f, inFrom := from.Properties[key]
+		t, inTo := to.Properties[key]
+		switch {
+		case inFrom && inTo:
+			switch {
+			case f == nil && t != nil:
+				d.narrowed(p, "schema added for the property")
+			case f != nil && t == nil:
+				d.widened(p, "schema removed for the property")
+			case f != nil:
+				d.diff(f, t, p)
+			}
+		case inTo && from.AdditionalProperties:
+			d.narrowed(p, "property added, any value was allowed as an additional property")
+		case inTo:
+			d.widened(p, "property added")
+		case inFrom && to.AdditionalProperties:
+			d.widened(p, "property removed, any value is allowed as an additional property")
+		case inFrom:
+			d.narrowed(p, "property removed, additional properties are not allowed")
+		}
+
+		required := stringContains(from.Required, key)
+		if r := stringContains(to.Required, key); r && !required && !inFrom && !from.AdditionalProperties {
+			// Data for the old schema can't have the property, and data for the
+			// new schema must have it
+			d.add(Breaking, p, "property is now required")
+		} else if r && !required {
+			d.narrowed(p, "property is now required")
+		} else if !r && required {
+			d.widened(p, "property is no longer required")
+		}
+		deprecated := stringContains(from.Deprecated, key)
+		if dep := stringContains(to.Deprecated, key); dep != deprecated {
+			if dep {
+				d.add(Compatible, p, "property is now deprecated")
+			} else {
+				d.add(Compatible, p, "property is no longer deprecated")
+			}
+		}
+	}
+}
+
+// diffTaggedUnion adds the changes from the TaggedUnion from to the
+// TaggedUnion to at path.
+func (d *schemaDiff) diffTaggedUnion(from, to TaggedUnion, path string) {
+	if from.Discriminator != to.Discriminator {
+		d.add(Breaking, path, "discriminator changed from '%s' to '%s'", from.Discriminator, to.Discriminator)
+		return
+	}
+	for _, tag := range from.tags() {
+		if _, ok := to.Variants[tag]; !ok {
+			d.narrowed(path, "variant '%s' removed", tag)
+			continue
+		}
+		// Changes in a variant are for the same path, so the variant is named
+		sub := &schemaDiff{}
+		sub.diff(from.variantSchema(tag), to.variantSchema(tag), path)
+		for _, c := range sub.changes {
+			c.Reason += fmt.Sprintf(", in variant '%s'", tag)
+			d.changes = append(d.changes, c)
+		}
+	}
+	for _, tag := range to.tags() {
+		if _, ok := from.Variants[tag]; !ok {
+			d.widened(path, "variant '%s' added", tag)
+		}
+	}
+}
+
+// diffComposite adds the changes from the composite schema from to the
+// composite schema to at path, keyword is the JSON schema keyword for the
+// composite. If widens is true, schemas added to the end allows more values,
+// otherwise they allow fewer values.
+func (d *schemaDiff) diffComposite(keyword string, from, to []Schema, path string, widens bool) {
+	if len(from) != len(to) {
+		appended := len(to) > len(from) && reflect.DeepEqual(from, to[:len(from)])
+		removed := len(to) < len(from) && reflect.DeepEqual(from[:len(to)], to)
+		reason := "schemas added to %s"
+		if removed {
+			reason = "schemas removed from %s"
+		}
+		switch {
+		case keyword == "oneOf" || !appended && !removed:
+			d.add(Breaking, path, "%s changed from %d to %d schemas", keyword, len(from), len(to))
+		case appended == widens:
+			d.widened(path, reason, keyword)
+		default:
+			d.narrowed(path, reason, keyword)
+		}
+		return
+	}
+
+	// Values valid for more than one schema are rejected by oneOf, so any
+	// change to the values allowed by a schema may reject values that were
+	// valid for either version
+	sub := &schemaDiff{}
+	for i := range from {
+		sub.diff(from[i], to[i], path)
+	}
+	for _, c := range sub.changes {
+		if keyword == "oneOf" && c.Compatibility != Compatible {
+			c.Compatibility = Breaking
+		}
+		c.Reason += fmt.Sprintf(", in %s", keyword)
+		d.changes = append(d.changes, c)
+	}
+}
+
+// diffOptions adds the changes from the enum options from to the options to at
+// path, and changes to the options deprecated.
+func (d *schemaDiff) diffOptions(path string, from, to, fromDeprecated, toDeprecated interface{}) {
+	contains := func(list interface{}, value reflect.Value) bool {
+		l := reflect.ValueOf(list)
+		for i := 0; i < l.Len(); i++ {
+			if l.Index(i).Interface() == value.Interface() {
+				return true
+			}
+		}
+		return false
+	}
+	f, t := reflect.ValueOf(from), reflect.ValueOf(to)
+	for i := 0; i < f.Len(); i++ {
+		if !contains(to, f.Index(i)) {
+			d.narrowed(path, "option %#v removed", f.Index(i).Interface())
+		}
+	}
+	for i := 0; i < t.Len(); i++ {
+		if !contains(from, t.Index(i)) {
+			d.widened(path, "option %#v added", t.Index(i).Interface())
+		} else if contains(toDeprecated, t.Index(i)) && !contains(fromDeprecated, t.Index(i)) {
+			d.add(Compatible, path, "option %#v is now deprecated", t.Index(i).Interface())
+		}
+	}
+}
+
+// lowerBound adds the change of the lower bound name from f to t at path.
+func (d *schemaDiff) lowerBound(path, name string, f, t int64) {
+	if f != t {
+		d.bound(t < f, path, name, f, t)
+	}
+}
+
+// upperBound adds the change of the upper bound name from f to t at path.
+func (d *schemaDiff) upperBound(path, name string, f, t int64) {
+	if f != t {
+		d.bound(t > f, path, name, f, t)
+	}
+}
+
+// bound adds the change of the bound name from f to t at path, widened is
+// true if t allows more values.
+func (d *schemaDiff) bound(widened bool, path, name string, f, t interface{}) {
+	format := func(v interface{}) interface{} {
+		switch v {
+		case int64(math.MinInt64), int64(math.MaxInt64), -math.MaxFloat64, math.MaxFloat64:
+			return "none"
+		}
+		if v == float64(0) && name == "multipleOf" {
+			return "none"
+		}
+		return v
+	}
+	if widened {
+		d.widened(path, "%s relaxed from %v to %v", name, format(f), format(t))
+	} else {
+		d.narrowed(path, "%s tightened from %v to %v", name, format(f), format(t))
+	}
+}
+
+// allowed adds the change of whether values described by name are allowed at
+// path.
+func (d *schemaDiff) allowed(path, name string, allowed bool) {
+	if allowed {
+		d.widened(path, "%s are now allowed", name)
+	} else {
+		d.narrowed(path, "%s are no longer allowed", name)
+	}
+}
+
+// unlimited returns math.MaxInt64 for the limit zero, which means no limit.
+func unlimited(limit int64) int64 {
+	if limit == 0 {
+		return math.MaxInt64
+	}
+	return limit
+}
+
+// metadataOf returns the title and description of s, if it has any.
+func metadataOf(s Schema) (title, description string) {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Struct {
+		return "", ""
+	}
+	if f := v.FieldByName("Title"); f.Kind() == reflect.String {
+		title = f.String()
+	}
+	if f := v.FieldByName("Description"); f.Kind() == reflect.String {
+		description = f.String()
+	}
+	return title, description
+}
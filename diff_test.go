@@ -0,0 +1,159 @@
+package schematypes
+
+import (
+	"testing"
+)
+
+// diffTestChanges returns the changes from Diff by path and reason
+func diffTestChanges(old, new Schema) map[string]Change {
+	changes := make(map[string]Change)
+	for _, c := range Diff(old, new) {
+		changes[c.Path+": "+c.Reason] = c
+	}
+	return changes
+}
+
+func TestDiff(t *testing.T) {
+	old := Object{
+		Title: "Config",
+		Properties: Properties{
+			"name":    String{MaximumLength: 10},
+			"size":    Integer{Minimum: 0, Maximum: 10},
+			"mode":    StringEnum{Options: []string{"fast", "slow"}},
+			"tags":    Array{Items: String{}},
+			"removed": Boolean{},
+		},
+		Required: []string{"name"},
+	}
+	new := Object{
+		Title: "Configuration",
+		Properties: Properties{
+			"name":  String{MaximumLength: 10},
+			"size":  Integer{Minimum: 0, Maximum: 20},
+			"mode":  StringEnum{Options: []string{"fast", "auto"}},
+			"tags":  Array{Items: String{Pattern: "^[a-z]+$"}},
+			"owner": String{},
+		},
+		Required: []string{"name", "owner"},
+	}
+
+	assert(len(Diff(old, old)) == 0, "Expected no changes for equal schemas")
+
+	changes := diffTestChanges(old, new)
+	expected := map[string]Compatibility{
+		": title or description changed":                                    Compatible,
+		".size: maximum relaxed from 10 to 20":                              BackwardCompatible,
+		".mode: option \"slow\" removed":                                    ForwardCompatible,
+		".mode: option \"auto\" added":                                      BackwardCompatible,
+		".tags[]: pattern \"^[a-z]+$\" added":                               ForwardCompatible,
+		".owner: property added":                                            BackwardCompatible,
+		".owner: property is now required":                                  Breaking,
+		".removed: property removed, additional properties are not allowed": ForwardCompatible,
+	}
+	for key, c := range expected {
+		assert(changes[key].Compatibility == c && changes[key].Reason != "",
+			"Expected change: ", key, " got: ", changes)
+	}
+	assert(len(changes) == len(expected), "Unexpected changes: ", changes)
+
+	c := changes[".size: maximum relaxed from 10 to 20"]
+	assert(c.String() == "backward-compatible change at root.size: maximum relaxed from 10 to 20",
+		"Unexpected string: ", c.String())
+	c = changes[".mode: option \"slow\" removed"]
+	assert(c.String() == "forward-compatible change at root.mode: option \"slow\" removed",
+		"Unexpected string: ", c.String())
+}
+
+func TestDiffTypes(t *testing.T) {
+	cases := []struct {
+		old, new Schema
+		c        Compatibility
+	}{
+		{Integer{Minimum: 0, Maximum: 5}, Number{Minimum: 0, Maximum: 10}, BackwardCompatible},
+		{Integer{Minimum: 0, Maximum: 5}, Number{Minimum: 0, Maximum: 10, MultipleOf: 0.5}, BackwardCompatible},
+		{Integer{Minimum: 0, Maximum: 5}, Number{Minimum: 1, Maximum: 10}, Breaking},
+		{Number{Minimum: 0, Maximum: 5}, Integer{Minimum: 0, Maximum: 5}, ForwardCompatible},
+		{Number{Minimum: 0, Maximum: 5, MultipleOf: 2}, Integer{Minimum: 0, Maximum: 5}, BackwardCompatible},
+		{Number{Minimum: 0, Maximum: 5, MultipleOf: 0.5}, Integer{Minimum: 0, Maximum: 5}, ForwardCompatible},
+		{Number{Minimum: 0, Maximum: 5, MultipleOf: 0.75}, Integer{Minimum: 0, Maximum: 5}, Breaking},
+		{IntegerEnum{Options: []int{1, 2}}, Integer{Minimum: 0, Maximum: 5}, BackwardCompatible},
+		{IntegerEnum{Options: []int{1, 7}}, Integer{Minimum: 0, Maximum: 5}, Breaking},
+		{StringEnum{Options: []string{"a"}}, String{}, BackwardCompatible},
+		{String{}, StringEnum{Options: []string{"a"}}, ForwardCompatible},
+		{String{Pattern: "^b"}, StringEnum{Options: []string{"a"}}, Breaking},
+		{Number{MultipleOf: 1}, Number{MultipleOf: 0.5}, BackwardCompatible},
+		{Number{MultipleOf: 1}, Number{MultipleOf: 2}, ForwardCompatible},
+		{Number{MultipleOf: 2}, Number{MultipleOf: 3}, Breaking},
+		{Number{}, Number{MultipleOf: 2}, ForwardCompatible},
+		{Number{Maximum: 1}, Number{Maximum: 2}, BackwardCompatible},
+		{String{Pattern: "a"}, String{}, BackwardCompatible},
+		{String{}, String{Pattern: "a"}, ForwardCompatible},
+		{String{Pattern: "a"}, String{Pattern: "b"}, Breaking},
+		{String{MaximumLength: 5}, String{}, BackwardCompatible},
+		{String{}, String{MaximumLength: 5}, ForwardCompatible},
+		{Array{Items: String{}}, Array{Items: String{}, Unique: true}, ForwardCompatible},
+		{Map{Values: Integer{Maximum: 1}}, Map{Values: Integer{Maximum: 2}}, BackwardCompatible},
+		{Duration{}, Duration{AllowNegative: true}, BackwardCompatible},
+		{Boolean{}, Boolean{Description: "flag"}, Compatible},
+		{Object{}, Object{AdditionalProperties: true}, BackwardCompatible},
+		{Object{AdditionalProperties: true},
+			Object{Properties: Properties{"a": String{}}, AdditionalProperties: true}, ForwardCompatible},
+		{Object{Properties: Properties{"a": String{}}, AdditionalProperties: true},
+			Object{AdditionalProperties: true}, BackwardCompatible},
+		{Object{Properties: Properties{"a": String{}}, Required: []string{"a"}},
+			Object{Properties: Properties{"a": String{}}}, BackwardCompatible},
+		{Object{Properties: Properties{"a": String{}}},
+			Object{Properties: Properties{"a": String{}}, Deprecated: []string{"a"}}, Compatible},
+		{Object{Properties: Properties{"a": String{}}},
+			Object{Properties: Properties{"a": String{}}, Required: []string{"a"}}, ForwardCompatible},
+		{Object{AdditionalProperties: true},
+			Object{AdditionalProperties: true, Required: []string{"a"}}, ForwardCompatible},
+		{Object{}, Object{Required: []string{"a"}}, Breaking},
+		{AnyOf{String{}}, AnyOf{String{}, Integer{}}, BackwardCompatible},
+		{AnyOf{String{}, Integer{}}, AnyOf{String{}}, ForwardCompatible},
+		{AnyOf{String{}, Integer{}}, AnyOf{Integer{}}, Breaking},
+		{AllOf{String{}}, AllOf{String{}, String{Pattern: "a"}}, ForwardCompatible},
+		{AllOf{String{}, String{Pattern: "a"}}, AllOf{String{}}, BackwardCompatible},
+		{OneOf{String{}}, OneOf{String{}, Integer{}}, Breaking},
+		{AnyOf{Integer{Maximum: 1}}, AnyOf{Integer{Maximum: 2}}, BackwardCompatible},
+		{OneOf{Integer{Maximum: 1}}, OneOf{Integer{Maximum: 2}}, Breaking},
+		{OneOf{Integer{Maximum: 2}}, OneOf{Integer{Maximum: 1}}, Breaking},
+		{Array{}, Array{Items: String{}}, ForwardCompatible},
+		{Array{Items: String{}}, Array{}, BackwardCompatible},
+		{Map{Values: Integer{}}, Map{}, BackwardCompatible},
+		{AnyOf{nil}, AnyOf{String{}}, ForwardCompatible},
+		{Object{Properties: Properties{"a": nil}}, Object{Properties: Properties{"a": String{}}}, ForwardCompatible},
+		{String{}, Integer{}, Breaking},
+	}
+	for _, c := range cases {
+		changes := Diff(c.old, c.new)
+		assert(len(changes) == 1, "Expected a single change for: ", c.old, " to ", c.new, " got: ", changes)
+		assert(changes[0].Compatibility == c.c,
+			"Unexpected change for: ", c.old, " to ", c.new, " got: ", changes[0])
+	}
+}
+
+func TestDiffTaggedUnion(t *testing.T) {
+	variant := func(max int64) Variant {
+		return Variant{Schema: Object{Properties: Properties{"size": Integer{Maximum: max}}}}
+	}
+	old := TaggedUnion{Discriminator: "kind", Variants: map[string]Variant{
+		"a": variant(1),
+		"b": variant(1),
+	}}
+	new := TaggedUnion{Discriminator: "kind", Variants: map[string]Variant{
+		"a": variant(2),
+		"c": variant(1),
+	}}
+	changes := diffTestChanges(old, new)
+	assert(changes[": variant 'b' removed"].Compatibility == ForwardCompatible, "Unexpected changes: ", changes)
+	assert(changes[": variant 'c' added"].Compatibility == BackwardCompatible, "Unexpected changes: ", changes)
+	c, ok := changes[".size: maximum relaxed from 1 to 2, in variant 'a'"]
+	assert(ok && c.Compatibility == BackwardCompatible, "Unexpected changes: ", changes)
+	assert(len(changes) == 3, "Unexpected changes: ", changes)
+
+	new.Discriminator = "type"
+	changes = diffTestChanges(old, new)
+	assert(changes[": discriminator changed from 'kind' to 'type'"].Compatibility == Breaking,
+		"Unexpected changes: ", changes)
+}
@@ -1,38 +1,433 @@
 package schematypes
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // ErrTypeMismatch is returned when trying to map to a type that doesn't match
 // or which isn't writable (for example passed by value and not pointer).
+//
+// Map returns a TypeMismatchError with details, use errors.Is to test for
+// ErrTypeMismatch.
 var ErrTypeMismatch = errors.New("Type does not match the schema")
 
+// TypeMismatchError is returned when trying to map to a type that doesn't
+// match the schema, errors.Is(err, ErrTypeMismatch) is true for this error.
+type TypeMismatchError struct {
+	// Path to the value that couldn't be mapped, on the same form as
+	// ValidationIssue.Path() without a root name.
+	Path string
+	// SchemaType is the name of the schema type, such as "Integer".
+	SchemaType string
+	// TargetType is the Go type that the value couldn't be mapped to.
+	TargetType reflect.Type
+	// Reason explains the mismatch, if known.
+	Reason string
+}
+
+func typeMismatch(schema Schema, target interface{}) *TypeMismatchError {
+	t := reflect.TypeOf(target)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return &TypeMismatchError{
+		SchemaType: reflect.TypeOf(schema).Name(),
+		TargetType: t,
+	}
+}
+
+func (e *TypeMismatchError) Error() string {
+	msg := fmt.Sprintf("Type %v at root%s does not match the schema type %s",
+		e.TargetType, e.Path, e.SchemaType)
+	if e.Reason != "" {
+		msg += ", " + e.Reason
+	}
+	return msg
+}
+
+// Is returns true if target is ErrTypeMismatch.
+func (e *TypeMismatchError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// TypeCheckError is returned by CheckType with all the mismatches found,
+// errors.Is(err, ErrTypeMismatch) is true for this error.
+type TypeCheckError struct {
+	Mismatches []*TypeMismatchError
+}
+
+func (e *TypeCheckError) Error() string {
+	msgs := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		msgs[i] = m.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is returns true if target is ErrTypeMismatch.
+func (e *TypeCheckError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// prefixMapError returns err from mapping the sub-schema at location l, with
+// l as prefix of the path. This prefixes TypeMismatchError and
+// ValidationError, other errors are returned as is.
+func prefixMapError(err error, l location) error {
+	switch e := err.(type) {
+	case *TypeMismatchError:
+		path := ""
+		for _, p := range l.data {
+			path += p.String()
+		}
+		return &TypeMismatchError{
+			Path:       path + e.Path,
+			SchemaType: e.SchemaType,
+			TargetType: e.TargetType,
+			Reason:     e.Reason,
+		}
+	case *ValidationError:
+		result := &ValidationError{}
+		result.addIssuesAt(e, l)
+		return result
+	}
+	return err
+}
+
+// PrefixError returns err with path as prefix to the paths in it, if err is a
+// TypeMismatchError or ValidationError, otherwise err is returned as is. The
+// path is on the form used by TypeMismatchError.Path, such as `.a[0]["b c"]`.
+//
+// This is used by the code written by Generate, to report errors at the same
+// paths as Map does.
+func PrefixError(err error, path string) error {
+	return prefixMapError(err, location{data: parsePath(path)})
+}
+
+// parsePath returns the elements of path, which is on the form written by
+// pathElement.String(). Text that can't be parsed is taken as a key.
+func parsePath(path string) []pathElement {
+	var elements []pathElement
+	for path != "" {
+		switch {
+		case path[0] == '.':
+			i := 1
+			for i < len(path) && path[i] != '.' && path[i] != '[' {
+				i++
+			}
+			elements = append(elements, keyElement(path[1:i]))
+			path = path[i:]
+			continue
+		case strings.HasPrefix(path, "[\""):
+			d := json.NewDecoder(strings.NewReader(path[1:]))
+			var key string
+			if d.Decode(&key) == nil {
+				if rest := path[1+d.InputOffset():]; strings.HasPrefix(rest, "]") {
+					elements = append(elements, keyElement(key))
+					path = rest[1:]
+					continue
+				}
+			}
+		case path[0] == '[':
+			if i := strings.IndexByte(path, ']'); i != -1 {
+				if index, err := strconv.Atoi(path[1:i]); err == nil && index >= 0 {
+					elements = append(elements, indexElement(index))
+					path = path[i+1:]
+					continue
+				}
+			}
+		}
+		return append(elements, keyElement(path))
+	}
+	return elements
+}
+
+// A pathElement is a single step into a JSON value, either an object key or
+// an array index.
+type pathElement struct {
+	key   string
+	index int // -1 if this is an object key
+}
+
+func keyElement(key string) pathElement {
+	return pathElement{key: key, index: -1}
+}
+
+func indexElement(index int) pathElement {
+	return pathElement{index: index}
+}
+
+// String returns the element on the form used in ValidationIssue.Path()
+func (p pathElement) String() string {
+	if p.index < 0 {
+		return formatKeyPath(p.key)
+	}
+	return "[" + strconv.Itoa(p.index) + "]"
+}
+
+// token returns the element as a JSON pointer reference token.
+func (p pathElement) token() string {
+	if p.index < 0 {
+		return escapePointerToken(p.key)
+	}
+	return strconv.Itoa(p.index)
+}
+
+func escapePointerToken(token string) string {
+	token = strings.Replace(token, "~", "~0", -1)
+	return strings.Replace(token, "/", "~1", -1)
+}
+
+// A location is a step from a schema to one of its sub-schemas or keywords.
+// It holds the path elements into the data and the keywords taken in the
+// schema to get there.
+type location struct {
+	data   []pathElement
+	schema []string
+	// partial is true if only the last keyword in schema is known, so the
+	// location of the keyword can't be given.
+	partial bool
+}
+
+// propertyLocation is the location of the sub-schema for property key.
+func propertyLocation(key string) location {
+	return location{
+		data:   []pathElement{keyElement(key)},
+		schema: []string{"properties", key},
+	}
+}
+
+// itemLocation is the location of the items sub-schema for index i.
+func itemLocation(i int) location {
+	return location{
+		data:   []pathElement{indexElement(i)},
+		schema: []string{"items"},
+	}
+}
+
+// valueLocation is the location of the additionalProperties sub-schema for
+// the value of key.
+func valueLocation(key string) location {
+	return location{
+		data:   []pathElement{keyElement(key)},
+		schema: []string{"additionalProperties"},
+	}
+}
+
+// subSchemaLocation is the location of the i'th sub-schema of keyword.
+func subSchemaLocation(keyword string, i int) location {
+	return location{schema: []string{keyword, strconv.Itoa(i)}}
+}
+
 // A ValidationIssue is any error found validating a JSON object.
 type ValidationIssue struct {
 	message string
-	path    string
+	root    string
+	// locations from the root schema to the keyword that caused the issue
+	locations []location
+	// causes are issues from sub-schemas that explains this issue, bestMatch
+	// is the index of the sub-schema whose causes are most relevant.
+	causes    []ValidationIssue
+	bestMatch int
+	// limitExceeded is true if the issue is that a limit was exceeded
+	limitExceeded bool
+	// property is the name of the missing property, for issues that a
+	// required property is missing
+	property string
+	// position in the JSON text, if known
+	position *Position
+}
+
+// A Position is a location in JSON text.
+type Position struct {
+	// Offset is the number of bytes before the position.
+	Offset int64
+	// Line and Column of the position starting from 1, Column is counted in
+	// bytes. These are zero if the JSON text isn't available.
+	Line   int
+	Column int
+}
+
+// String returns the position on the form: line:column
+func (p Position) String() string {
+	if p.Line == 0 {
+		return "offset " + strconv.FormatInt(p.Offset, 10)
+	}
+	return strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// Position returns the position in the JSON text of the value the issue is
+// for, or the closest parent value if the issue is for a missing property.
+// This is only known for issues from ValidateJSON and MapJSON, and for syntax
+// errors from Decode and Decoder.
+func (v *ValidationIssue) Position() (Position, bool) {
+	if v.position == nil {
+		return Position{}, false
+	}
+	return *v.position, true
 }
 
 // String returns a human readable string representation of the issue
 func (v *ValidationIssue) String() string {
-	return strings.Replace(v.message, "{path}", v.path, -1)
+	msg := strings.Replace(v.message, "{path}", v.Path(), -1)
+	var hints []string
+	for _, cause := range v.BestMatch() {
+		hints = append(hints, cause.String())
+	}
+	if len(hints) > 0 {
+		msg += " (" + strings.Join(hints, ", ") + ")"
+	}
+	return msg
+}
+
+// LimitExceeded returns true, if the issue is that the value exceeded one of
+// the limits given in Options, rather than an issue with the value.
+func (v *ValidationIssue) LimitExceeded() bool {
+	return v.limitExceeded
+}
+
+// Error returns a human readable string representation of the issue, this
+// allows errors.As to extract a ValidationIssue from a ValidationError.
+func (v *ValidationIssue) Error() string {
+	return v.String()
+}
+
+// Causes returns the issues from sub-schemas that caused this issue, for
+// example the issues for each of the options in an anyOf schema.
+func (v *ValidationIssue) Causes() []ValidationIssue {
+	causes := make([]ValidationIssue, len(v.causes))
+	for i, cause := range v.causes {
+		causes[i] = cause
+		causes[i].root = v.root
+	}
+	return causes
+}
+
+// BestMatch returns the subset of Causes() from the sub-schema that was
+// heuristically determined to be the closest match for the value.
+func (v *ValidationIssue) BestMatch() []ValidationIssue {
+	var causes []ValidationIssue
+	depth := len(v.locations) - 1
+	for _, cause := range v.Causes() {
+		if depth < 0 || len(cause.locations) <= depth {
+			continue
+		}
+		k := cause.locations[depth].schema
+		if len(k) == 2 && k[1] == strconv.Itoa(v.bestMatch) {
+			causes = append(causes, cause)
+		}
+	}
+	return causes
 }
 
 // Path returns the a path to the issue, on the form:
 //   rootName.dictionary["other-key"].array[44].property
 func (v *ValidationIssue) Path() string {
-	return v.path
+	path := v.root
+	for _, l := range v.locations {
+		for _, p := range l.data {
+			path += p.String()
+		}
+	}
+	return path
 }
 
-// prefix will add a prefix to the path to property that had an issue.
-func (v *ValidationIssue) prefix(prefix string, args ...interface{}) ValidationIssue {
-	return ValidationIssue{
-		message: v.message,
-		path:    fmt.Sprintf(prefix, args...) + v.path,
+// InstanceLocation returns a JSON pointer to the value that had an issue, on
+// the form: /dictionary/other-key/array/44/property
+//
+// For a missing required property this is the object that is missing the
+// property, while Path() includes the property, as the name of the property
+// is a detail of the issue.
+func (v *ValidationIssue) InstanceLocation() string {
+	elements := v.elements()
+	if v.property != "" {
+		elements = elements[:len(elements)-1]
+	}
+	pointer := ""
+	for _, p := range elements {
+		pointer += "/" + p.token()
+	}
+	return pointer
+}
+
+// KeywordLocation returns a JSON pointer to the schema keyword that caused
+// the issue, on the form: /properties/array/items/minimum
+//
+// This is the empty string if the location isn't known, which is the case for
+// issues from schemas created with NewSchema.
+func (v *ValidationIssue) KeywordLocation() string {
+	pointer := ""
+	for _, l := range v.locations {
+		if l.partial {
+			return ""
+		}
+		for _, k := range l.schema {
+			pointer += "/" + escapePointerToken(k)
+		}
+	}
+	return pointer
+}
+
+// Keyword returns the JSON schema keyword that caused the issue, such as
+// "minimum" or "required". This is the empty string if the issue wasn't
+// caused by a keyword.
+func (v *ValidationIssue) Keyword() string {
+	if len(v.locations) == 0 {
+		return ""
+	}
+	k := v.locations[len(v.locations)-1].schema
+	if len(k) == 0 {
+		return ""
 	}
+	return k[len(k)-1]
+}
+
+// elements returns the path elements from the root to the issue.
+func (v *ValidationIssue) elements() []pathElement {
+	var elements []pathElement
+	for _, l := range v.locations {
+		elements = append(elements, l.data...)
+	}
+	return elements
+}
+
+// less returns true, if v should be ordered before other, issues are ordered
+// by path and then keyword.
+func (v *ValidationIssue) less(other *ValidationIssue) bool {
+	a, b := v.elements(), other.elements()
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		if a[i].index != b[i].index {
+			return a[i].index < b[i].index // keys are -1, so they come first
+		}
+		return a[i].key < b[i].key
+	}
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return v.Keyword() < other.Keyword()
+}
+
+// prefix returns a copy of the issue with l as the outermost location.
+func (v *ValidationIssue) prefix(l location) ValidationIssue {
+	locations := make([]location, 0, len(v.locations)+1)
+	locations = append(locations, l)
+	locations = append(locations, v.locations...)
+	var causes []ValidationIssue
+	for _, cause := range v.causes {
+		causes = append(causes, cause.prefix(l))
+	}
+	issue := *v
+	issue.locations = locations
+	issue.causes = causes
+	return issue
 }
 
 // ValidationError represents a validation failure as a list of validation
@@ -49,49 +444,115 @@ func (e *ValidationError) Issues(rootName string) []ValidationIssue {
 	}
 	issues := make([]ValidationIssue, len(e.issues))
 	for i, issue := range e.issues {
-		issues[i] = issue.prefix("%s", rootName)
+		issues[i] = issue
+		issues[i].root = rootName
 	}
 	return issues
 }
 
-func (e *ValidationError) addIssue(path, message string, args ...interface{}) {
+// Sort orders the issues by path and then by keyword, issues with the same
+// path and keyword retain their order.
+func (e *ValidationError) Sort() {
+	sort.SliceStable(e.issues, func(i, j int) bool {
+		return e.issues[i].less(&e.issues[j])
+	})
+}
+
+// An IssueGroup is a list of issues for the same path.
+type IssueGroup struct {
+	Path   string
+	Issues []ValidationIssue
+}
+
+// Group returns the issues grouped by path, with given rootName as the start
+// of the path or "root" if rootName is the empty string. Groups and issues
+// within a group are ordered as by Sort().
+func (e *ValidationError) Group(rootName string) []IssueGroup {
+	sorted := &ValidationError{issues: e.Issues(rootName)}
+	sorted.Sort()
+	var groups []IssueGroup
+	for _, issue := range sorted.issues {
+		path := issue.Path()
+		if len(groups) == 0 || groups[len(groups)-1].Path != path {
+			groups = append(groups, IssueGroup{Path: path})
+		}
+		g := &groups[len(groups)-1]
+		g.Issues = append(g.Issues, issue)
+	}
+	return groups
+}
+
+// addIssue adds an issue caused by keyword for the value being validated.
+func (e *ValidationError) addIssue(keyword, message string, args ...interface{}) {
+	e.issues = append(e.issues, ValidationIssue{
+		message:   fmt.Sprintf(message, args...),
+		locations: []location{{schema: []string{keyword}}},
+	})
+}
+
+// addIssueAt adds an issue caused by keyword for the element p of the value
+// being validated.
+func (e *ValidationError) addIssueAt(p pathElement, keyword, message string, args ...interface{}) {
 	e.issues = append(e.issues, ValidationIssue{
 		message: fmt.Sprintf(message, args...),
-		path:    path,
+		locations: []location{{
+			data:   []pathElement{p},
+			schema: []string{keyword},
+		}},
 	})
 }
 
+// addMissing adds an issue that the required property key is missing from the
+// value being validated.
+func (e *ValidationError) addMissing(key string) {
+	e.addIssueAt(keyElement(key), "required", "Required property '%s' is missing at {path}", key)
+	e.issues[len(e.issues)-1].property = key
+}
+
 func (e *ValidationError) addIssues(err *ValidationError) {
 	e.issues = append(e.issues, err.issues...)
 }
 
-func (e *ValidationError) addIssuesWithPrefix(err error, prefix string, args ...interface{}) {
+// addIssuesAt adds the issues from err, which was returned from validating
+// the sub-schema at location l.
+func (e *ValidationError) addIssuesAt(err error, l location) {
 	if err == nil {
 		return
 	}
 	if err, ok := err.(*ValidationError); ok {
 		for _, issue := range err.issues {
-			e.issues = append(e.issues, issue.prefix(prefix, args...))
+			e.issues = append(e.issues, issue.prefix(l))
 		}
 	} else {
 		issue := ValidationIssue{
-			message: fmt.Sprintf("Error: %s at {path}", err.Error()),
-			path:    "",
+			message:   fmt.Sprintf("Error: %s at {path}", err.Error()),
+			locations: []location{l},
 		}
-		e.issues = append(e.issues, issue.prefix(prefix, args...))
+		e.issues = append(e.issues, issue)
 	}
 }
 
-func singleIssue(path, message string, args ...interface{}) *ValidationError {
+func singleIssue(keyword, message string, args ...interface{}) *ValidationError {
 	e := &ValidationError{}
-	e.addIssue(path, message, args...)
+	e.addIssue(keyword, message, args...)
 	return e
 }
 
+// Unwrap returns the issues as errors, with paths starting from "root".
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.issues))
+	for i, issue := range e.Issues("") {
+		issue := issue
+		errs[i] = &issue
+	}
+	return errs
+}
+
 func (e *ValidationError) Error() string {
 	msg := "ValidationError: "
 	for _, issue := range e.issues {
-		msg += strings.Replace(issue.message, "{path}", "root"+issue.path, -1) + ", "
+		issue.root = "root"
+		msg += issue.String() + ", "
 	}
 	return msg
 }
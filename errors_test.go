@@ -0,0 +1,118 @@
+package schematypes
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidationErrorDeterministic(t *testing.T) {
+	s := Object{
+		Properties: Properties{
+			"a": Integer{Minimum: 0, Maximum: 1},
+			"b": Integer{Minimum: 0, Maximum: 1},
+			"c": Map{Values: String{MaximumLength: 1}},
+		},
+		Required: []string{"d", "e"},
+	}
+	var data interface{}
+	nilOrPanic(json.Unmarshal([]byte(`{
+		"x": 1, "b": 5, "a": 5, "y": 1,
+		"c": {"k1": "long", "k2": "long", "k3": "long", "k4": "long"}
+	}`), &data), "Internal test error")
+
+	expected := s.Validate(data).Error()
+	for i := 0; i < 20; i++ {
+		assert(s.Validate(data).Error() == expected, "Expected deterministic ordering")
+	}
+
+	e := s.Validate(data).(*ValidationError)
+	paths := []string{}
+	for _, issue := range e.Issues("") {
+		paths = append(paths, issue.Path())
+	}
+	assertJSON(paths, `[
+		"root.a", "root.b", "root.c.k1", "root.c.k2", "root.c.k3", "root.c.k4",
+		"root.d", "root.e", "root.x", "root.y"
+	]`, "Unexpected ordering: ", paths)
+}
+
+func TestValidationErrorSort(t *testing.T) {
+	e := &ValidationError{}
+	e.addIssueAt(indexElement(10), "maximum", "a")
+	e.addIssueAt(indexElement(2), "minimum", "b")
+	e.addIssueAt(indexElement(2), "maximum", "c")
+	e.addIssue("type", "d")
+	e.Sort()
+	msgs := []string{}
+	for _, issue := range e.issues {
+		msgs = append(msgs, issue.message)
+	}
+	assertJSON(msgs, `["d", "c", "b", "a"]`, "Unexpected ordering: ", msgs)
+}
+
+func TestValidationErrorGroup(t *testing.T) {
+	s := Array{Items: String{MinimumLength: 5, Pattern: "^[0-9]+$"}}
+	e := s.Validate([]interface{}{"abc", "12345", "x"}).(*ValidationError)
+	groups := e.Group("list")
+	assert(len(groups) == 2, "Expected two groups")
+	assert(groups[0].Path == "list[0]", "Unexpected path: ", groups[0].Path)
+	assert(groups[1].Path == "list[2]", "Unexpected path: ", groups[1].Path)
+	for _, g := range groups {
+		assert(len(g.Issues) == 2, "Expected two issues in each group")
+		assert(g.Issues[0].Keyword() == "minLength", "Expected minLength first")
+		assert(g.Issues[1].Keyword() == "pattern", "Expected pattern second")
+	}
+}
+
+func TestTypeMismatchError(t *testing.T) {
+	s := Object{
+		Properties: Properties{
+			"list": Array{Items: Map{Values: Integer{Minimum: 0, Maximum: 1000}}},
+		},
+	}
+	var target struct {
+		List []map[string]int8 `json:"list"`
+	}
+	data := map[string]interface{}{
+		"list": []interface{}{map[string]interface{}{"a": float64(1)}},
+	}
+	err := s.Map(data, &target)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected ErrTypeMismatch, got: ", err)
+	var e *TypeMismatchError
+	assert(errors.As(err, &e), "Expected a TypeMismatchError")
+	assert(e.Path == `.list[0].a`, "Unexpected path: ", e.Path)
+	assert(e.SchemaType == "Integer", "Unexpected schema type: ", e.SchemaType)
+	assert(e.TargetType == reflect.TypeOf(int8(0)), "Unexpected target type: ", e.TargetType)
+
+	var missing struct {
+		Other int `json:"other"`
+	}
+	err = s.Map(map[string]interface{}{}, &missing)
+	assert(errors.As(err, &e), "Expected a TypeMismatchError")
+	assert(e.Path == `.list`, "Unexpected path: ", e.Path)
+}
+
+func TestValidationErrorUnwrap(t *testing.T) {
+	err := Object{Required: []string{"a", "b"}}.Validate(map[string]interface{}{})
+	var issue *ValidationIssue
+	assert(errors.As(err, &issue), "Expected a ValidationIssue")
+	assert(issue.Path() == "root.a", "Unexpected path: ", issue.Path())
+	assert(issue.Error() == "Required property 'a' is missing at root.a",
+		"Unexpected message: ", issue.Error())
+	assert(len(err.(*ValidationError).Unwrap()) == 2, "Expected two issues")
+}
+
+func TestPrefixError(t *testing.T) {
+	err := PrefixError(singleIssue("format", "Value at {path} is bad"), `.a[2]["b.c[0]"].d`)
+	e, ok := err.(*ValidationError)
+	assert(ok, "Expected a ValidationError, got: ", err)
+	assert(e.Issues("")[0].Path() == `root.a[2]["b.c[0]"].d`, "Unexpected path: ", e.Issues("")[0].Path())
+
+	err = PrefixError(&TypeMismatchError{Path: ".x", SchemaType: "String"}, `["a b"]`)
+	assert(err.(*TypeMismatchError).Path == `["a b"].x`, "Unexpected path: ", err)
+
+	other := errors.New("other")
+	assert(PrefixError(other, ".a") == other, "Expected other errors as is")
+}
@@ -0,0 +1,283 @@
+package schematypes
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A structField is a field of a struct type, resolved using the same rules as
+// encoding/json, including promotion of fields from embedded structs.
+type structField struct {
+	name      string // name from the json tag or the Go field name
+	index     []int  // index sequence for reflect.Value.FieldByIndex
+	typ       reflect.Type
+	tagged    bool // true, if name was given in the json tag
+	omitEmpty bool // true, if the json tag has the omitempty option
+	quoted    bool // true, if the json tag has the string option
+}
+
+// fieldCache holds the []structField for each struct type.
+var fieldCache sync.Map
+
+// structFields returns the fields of the struct type t, following the rules
+// encoding/json uses to resolve field names. The result is cached and must not
+// be modified.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return fields.([]structField)
+}
+
+// typeFields returns the fields of the struct type t, like structFields but
+// without caching.
+func typeFields(t reflect.Type) []structField {
+	type visit struct {
+		typ   reflect.Type
+		index []int
+	}
+	current := []visit{}
+	next := []visit{{typ: t}}
+	visited := map[reflect.Type]bool{}
+
+	// Fields found at each depth, fields at a lower depth dominates
+	var fields []structField
+	count := map[reflect.Type]int{}     // embedded structs of a type at the current depth
+	nextCount := map[reflect.Type]int{} // embedded structs of a type at the next depth
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, v := range current {
+			if visited[v.typ] {
+				continue
+			}
+			visited[v.typ] = true
+
+			for i := 0; i < v.typ.NumField(); i++ {
+				f := v.typ.Field(i)
+				if f.Anonymous {
+					ft := f.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if f.PkgPath != "" && ft.Kind() != reflect.Struct {
+						continue // ignore embedded fields of unexported non-struct types
+					}
+				} else if f.PkgPath != "" {
+					continue // ignore unexported fields
+				}
+
+				tag := f.Tag.Get("json")
+				if tag == "-" || isRemainField(f) {
+					continue
+				}
+				name, options := tag, ""
+				if i := strings.Index(tag, ","); i != -1 {
+					name, options = tag[:i], tag[i:]
+				}
+
+				index := make([]int, len(v.index)+1)
+				copy(index, v.index)
+				index[len(v.index)] = i
+
+				ft := f.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				// Record the field, unless it is an untagged embedded struct
+				if name != "" || !f.Anonymous || ft.Kind() != reflect.Struct {
+					tagged := name != ""
+					if name == "" {
+						name = f.Name
+					}
+					quoted := false
+					if strings.Contains(options, ",string") {
+						switch ft.Kind() {
+						case reflect.Bool, reflect.Float32, reflect.Float64, reflect.String,
+							reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+							reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+							quoted = true
+						}
+					}
+					fields = append(fields, structField{
+						name:      name,
+						index:     index,
+						typ:       f.Type,
+						tagged:    tagged,
+						omitEmpty: strings.Contains(options, ",omitempty"),
+						quoted:    quoted,
+					})
+					if count[v.typ] > 1 {
+						// The struct is embedded more than once at this depth, add
+						// a second copy, so the field is dropped as a conflict.
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				// Visit the embedded struct at the next depth
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, visit{typ: ft, index: index})
+				}
+			}
+		}
+	}
+
+	// Sort by name, then depth, then tagged before untagged
+	sort.SliceStable(fields, func(i, j int) bool {
+		x, y := fields[i], fields[j]
+		if x.name != y.name {
+			return x.name < y.name
+		}
+		if len(x.index) != len(y.index) {
+			return len(x.index) < len(y.index)
+		}
+		return x.tagged && !y.tagged
+	})
+
+	// Keep the dominant field for each name, dropping names with conflicts
+	result := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if f, ok := dominantField(fields[i:j]); ok {
+			result = append(result, f)
+		}
+		i = j
+	}
+
+	// Order fields by index sequence, as they are declared
+	sort.Slice(result, func(i, j int) bool {
+		x, y := result[i].index, result[j].index
+		for k := 0; k < len(x) && k < len(y); k++ {
+			if x[k] != y[k] {
+				return x[k] < y[k]
+			}
+		}
+		return len(x) < len(y)
+	})
+	return result
+}
+
+// isRemainField returns true, if f is tagged `schema:",remain"`.
+func isRemainField(f reflect.StructField) bool {
+	tag := f.Tag.Get("schema")
+	if i := strings.Index(tag, ","); i != -1 {
+		for _, option := range strings.Split(tag[i+1:], ",") {
+			if option == "remain" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// remainField returns the field of the struct type t tagged
+// `schema:",remain"`, which receives the additional properties of an object.
+// Fields declared directly in t are found first, then fields in embedded
+// structs, which remainReason reports as unsupported. The field isn't resolved
+// as a property by structFields.
+func remainField(t reflect.Type) (structField, bool) {
+	type visit struct {
+		typ   reflect.Type
+		index []int
+	}
+	current := []visit{{typ: t}}
+	visited := map[reflect.Type]bool{}
+	for len(current) > 0 {
+		var next []visit
+		for _, v := range current {
+			if visited[v.typ] {
+				continue
+			}
+			visited[v.typ] = true
+
+			for i := 0; i < v.typ.NumField(); i++ {
+				f := v.typ.Field(i)
+				index := append(append([]int(nil), v.index...), i)
+				if f.PkgPath == "" && isRemainField(f) {
+					return structField{name: f.Name, index: index, typ: f.Type}, true
+				}
+				if ft := f.Type; f.Anonymous {
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct {
+						next = append(next, visit{typ: ft, index: index})
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return structField{}, false
+}
+
+// dominantField returns the field that dominates fields with the same name,
+// sorted by depth and tagged first. If no field dominates, it returns false.
+func dominantField(fields []structField) (structField, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) &&
+		fields[0].tagged == fields[1].tagged {
+		return structField{}, false
+	}
+	return fields[0], true
+}
+
+// lookupField returns the field for the JSON property name, preferring an
+// exact match and falling back to a case-insensitive match.
+func lookupField(fields []structField, name string) (structField, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return structField{}, false
+}
+
+// fieldByIndex returns the field of v with the given index sequence,
+// allocating nil pointers to embedded structs as needed. It returns false, if
+// a pointer to an unexported embedded struct is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldValue returns the field of v with the given index sequence, it returns
+// false if a pointer to an embedded struct is nil.
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,684 @@
+package schematypes
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"math"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GenerateOptions holds the options for Generate.
+type GenerateOptions struct {
+	// Package is the name of the package the code is generated for, this must
+	// be the package that declares the Target type.
+	Package string
+	// Name is used to name the generated functions MapName and ValidateName.
+	Name string
+	// Schema is the schema to generate code for.
+	Schema Object
+	// SchemaExpr is an expression for Schema in the generated package, such as
+	// the name of the variable that holds Schema.
+	SchemaExpr string
+	// Target is a value of the struct type to map into.
+	Target interface{}
+}
+
+// Generate writes Go source with functions for mapping data into the Target
+// struct without reflection, for use with go generate:
+//
+//	// ValidateName validates data against the schema.
+//	func ValidateName(data interface{}) error
+//
+//	// MapName validates data against the schema and maps it into a Target.
+//	func MapName(data interface{}) (Target, error)
+//
+// These have the same semantics as Object.Map, but the Target type is checked
+// when the code is generated, so Generate returns the error from CheckType, if
+// the Target type doesn't match the schema. Values that can't be mapped
+// without reflection, such as values for types implementing Mapper, are mapped
+// with the Map method of their sub-schema.
+//
+// The generated code uses SchemaExpr for validation, so the schema must not be
+// changed without generating the code again. To generate code put a program
+// like this in a file with the ignore build tag:
+//
+//	func main() {
+//		f, _ := os.Create("config_mapper.go")
+//		defer f.Close()
+//		err := schematypes.Generate(f, schematypes.GenerateOptions{
+//			Package:    "config",
+//			Name:       "Config",
+//			Schema:     config.Schema,
+//			SchemaExpr: "Schema",
+//			Target:     config.Config{},
+//		})
+//		...
+//	}
+//
+// and run it with "//go:generate go run gen.go" in the package.
+func Generate(w io.Writer, options GenerateOptions) error {
+	t := reflect.TypeOf(options.Target)
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("schematypes: Target must be a struct, got %v", t)
+	}
+
+	if err := CheckType(options.Schema, t); err != nil {
+		return err
+	}
+
+	g := &generator{
+		name:     options.Name,
+		pkgPath:  t.PkgPath(),
+		imports:  make(map[string]bool),
+		packages: make(map[string]string),
+		helpers:  make(map[string]bool),
+	}
+	typeName, err := g.typeExpr(t)
+	if err != nil {
+		return err
+	}
+	root := schemaExpr{expr: options.SchemaExpr, static: "Object"}
+	if err := g.gen(options.Schema, root, "data", "(*result)", t, nil); err != nil {
+		return err
+	}
+
+	var helpers bytes.Buffer
+	g.writeHelpers(&helpers)
+
+	// Write the functions calling the generated code
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "// Validate%s validates data against %s, this returns nil if data\n", g.name, options.SchemaExpr)
+	fmt.Fprintf(&body, "// satisfies the schema, otherwise it returns a ValidationError.\n")
+	fmt.Fprintf(&body, "func Validate%s(data interface{}) error {\n", g.name)
+	fmt.Fprintf(&body, "return %s.Validate(data)\n}\n\n", options.SchemaExpr)
+	fmt.Fprintf(&body, "// Map%s validates data against %s and maps it into a %s.\n", g.name, options.SchemaExpr, typeName)
+	fmt.Fprintf(&body, "func Map%s(data interface{}) (%s, error) {\n", g.name, typeName)
+	fmt.Fprintf(&body, "var result %s\n", typeName)
+	fmt.Fprintf(&body, "if err := Validate%s(data); err != nil {\nreturn result, err\n}\n", g.name)
+	fmt.Fprintf(&body, "err := map%s(data, &result)\nreturn result, err\n}\n\n", g.name)
+	fmt.Fprintf(&body, "func map%s(data interface{}, result *%s) error {\n", g.name, typeName)
+	body.Write(g.code.Bytes())
+	fmt.Fprintf(&body, "return nil\n}\n")
+	body.Write(helpers.Bytes())
+
+	// Import the packages used, code that referred to a package may have been
+	// dropped for a fallback
+	used, err := packagesUsed(body.Bytes())
+	if err != nil {
+		return fmt.Errorf("schematypes: cannot generate valid code for %v, error: %s", t, err)
+	}
+	var imports []string
+	for p := range g.imports {
+		if used[path.Base(p)] {
+			imports = append(imports, strconv.Quote(p))
+		}
+	}
+	for p, name := range g.packages {
+		if !used[name] {
+			continue
+		}
+		if name != path.Base(p) {
+			imports = append(imports, name+" "+strconv.Quote(p))
+		} else {
+			imports = append(imports, strconv.Quote(p))
+		}
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		return importPath(imports[i]) < importPath(imports[j])
+	})
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by schematypes.Generate. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", options.Package)
+	if len(imports) > 0 {
+		fmt.Fprintf(&b, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+	b.Write(body.Bytes())
+
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		return fmt.Errorf("schematypes: cannot generate valid code for %v, error: %s", t, err)
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+// packagesUsed returns the names of the packages referred to by the Go
+// declarations in code.
+func packagesUsed(code []byte) (map[string]bool, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", append([]byte("package p\n"), code...), 0)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if s, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := s.X.(*ast.Ident); ok {
+				used[x.Name] = true
+			}
+		}
+		return true
+	})
+	return used, nil
+}
+
+// importPath returns the path from the import spec, which may have a name.
+func importPath(spec string) string {
+	return spec[strings.Index(spec, "\""):]
+}
+
+// A generator holds the state for generating the code in Generate.
+type generator struct {
+	name     string
+	pkgPath  string // path of the generated package
+	code     bytes.Buffer
+	imports  map[string]bool   // standard packages imported
+	packages map[string]string // other packages imported, by path, with their names
+	helpers  map[string]bool
+	n        int // counter for naming variables
+}
+
+// A schemaExpr is an expression for a sub-schema in the generated code.
+type schemaExpr struct {
+	expr   string
+	static string // name of the static type of expr, if not Schema
+}
+
+// field returns an expression for the field of the schema type named typeName.
+func (g *generator) field(e schemaExpr, typeName, field string) schemaExpr {
+	if e.static == typeName {
+		return schemaExpr{expr: e.expr + "." + field}
+	}
+	return schemaExpr{expr: e.expr + ".(" + g.qualify(typeName) + ")." + field}
+}
+
+// A pathExpr is an expression for the path to a value in the generated code,
+// as parts that are either literal text or expressions for strings.
+type pathExpr []pathPart
+
+type pathPart struct {
+	text string
+	expr bool
+}
+
+func (p pathExpr) literal(text string) pathExpr {
+	return append(p[:len(p):len(p)], pathPart{text: text})
+}
+
+func (p pathExpr) expr(expr string) pathExpr {
+	return append(p[:len(p):len(p)], pathPart{text: expr, expr: true})
+}
+
+// String returns p as a Go expression, joining consecutive literals.
+func (p pathExpr) String() string {
+	var parts []string
+	literal := ""
+	for _, part := range p {
+		if !part.expr {
+			literal += part.text
+			continue
+		}
+		if literal != "" {
+			parts = append(parts, strconv.Quote(literal))
+			literal = ""
+		}
+		parts = append(parts, part.text)
+	}
+	if literal != "" || len(parts) == 0 {
+		parts = append(parts, strconv.Quote(literal))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// selfPkgPath is the import path of this package.
+var selfPkgPath = reflect.TypeOf(Object{}).PkgPath()
+
+// qualify returns name qualified with this package, and imports it.
+func (g *generator) qualify(name string) string {
+	if g.pkgPath == selfPkgPath {
+		return name
+	}
+	return g.importPackage(selfPkgPath, "schematypes") + "." + name
+}
+
+// qualifyType returns the name of the named type t qualified with its package,
+// and imports the package if needed.
+func (g *generator) qualifyType(t reflect.Type) string {
+	if t.PkgPath() == "" || t.PkgPath() == g.pkgPath {
+		return t.Name()
+	}
+	// The package name is only given by the string for t, the last element of
+	// the path may differ, as for "example.com/go-types" or "example.com/v2"
+	pkgName := t.String()[:strings.Index(t.String(), ".")]
+	return g.importPackage(t.PkgPath(), pkgName) + "." + t.Name()
+}
+
+// generatedNames are the names used by the generated code, which imported
+// packages must not be named.
+var generatedNames = map[string]bool{
+	"fmt": true, "json": true, "math": true, "reflect": true, "strconv": true,
+	"schematypes": true, "data": true, "result": true, "err": true, "ok": true,
+}
+
+// importPackage imports the package at pkgPath and returns the name to use
+// for it, which is pkgName with a number appended, if pkgName is taken.
+func (g *generator) importPackage(pkgPath, pkgName string) string {
+	if name, ok := g.packages[pkgPath]; ok {
+		return name
+	}
+	taken := func(name string) bool {
+		if generatedNames[name] && pkgPath != selfPkgPath || isVariableName(name) {
+			return true
+		}
+		for p, n := range g.packages {
+			if n == name && p != pkgPath {
+				return true
+			}
+		}
+		return false
+	}
+	name := pkgName
+	for i := 2; taken(name); i++ {
+		name = pkgName + strconv.Itoa(i)
+	}
+	g.packages[pkgPath] = name
+	return name
+}
+
+// isVariableName returns true, if name may be returned by variable.
+func isVariableName(name string) bool {
+	if len(name) < 2 || !strings.ContainsRune("dfikmsvx", rune(name[0])) {
+		return false
+	}
+	_, err := strconv.ParseUint(name[1:], 10, 64)
+	return err == nil
+}
+
+// typeExpr returns an expression for the type t in the generated code.
+func (g *generator) typeExpr(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		return g.qualifyType(t), nil
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice:
+		elem, err := g.typeExpr(t.Elem())
+		if t.Kind() == reflect.Ptr {
+			return "*" + elem, err
+		}
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := g.typeExpr(t.Elem())
+		return "[" + strconv.Itoa(t.Len()) + "]" + elem, err
+	case reflect.Map:
+		key, err := g.typeExpr(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeExpr(t.Elem())
+		return "map[" + key + "]" + elem, err
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+	}
+	return "", fmt.Errorf("schematypes: cannot generate code for the unnamed type %v", t)
+}
+
+// variable returns a new variable name with the given prefix.
+func (g *generator) variable(prefix string) string {
+	g.n++
+	return prefix + strconv.Itoa(g.n)
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.code, format, args...)
+}
+
+// fieldOf returns an expression for the field name of target.
+func fieldOf(target, name string) string {
+	if strings.HasPrefix(target, "(*") && strings.HasSuffix(target, ")") {
+		target = target[2 : len(target)-1]
+	}
+	return target + "." + name
+}
+
+// addressOf returns an expression for the address of target.
+func addressOf(target string) string {
+	if strings.HasPrefix(target, "(*") && strings.HasSuffix(target, ")") {
+		return target[2 : len(target)-1]
+	}
+	return "&" + target
+}
+
+// assignable returns target without parentheses, for the left side of an
+// assignment.
+func assignable(target string) string {
+	if strings.HasPrefix(target, "(*") && strings.HasSuffix(target, ")") {
+		return target[1 : len(target)-1]
+	}
+	return target
+}
+
+// returnError writes a statement returning err from mapping the value at p.
+func (g *generator) returnError(err string, p pathExpr) {
+	if len(p) == 0 {
+		g.printf("return %s\n", err)
+		return
+	}
+	g.printf("return %s(%s, %s)\n", g.qualify("PrefixError"), err, p)
+}
+
+// mismatch returns a TypeMismatchError for mapping s into t at p, ignoring
+// the parts of p that are only known when mapping.
+func mismatch(s Schema, t reflect.Type, p pathExpr) *TypeMismatchError {
+	path := ""
+	for _, part := range p {
+		if part.expr {
+			path += "[]"
+		} else {
+			path += part.text
+		}
+	}
+	return &TypeMismatchError{
+		Path:       path,
+		SchemaType: reflect.TypeOf(s).Name(),
+		TargetType: t,
+	}
+}
+
+// gen writes code for mapping the value in the variable data into target,
+// which is an addressable expression of type t. The value must be validated
+// against s, which is the schema at e, and p is the path to the value.
+func (g *generator) gen(s Schema, e schemaExpr, data, target string, t reflect.Type, p pathExpr) error {
+	if t.Kind() == reflect.Ptr || t == typeOfEmptyInterface || hasHook(t) {
+		g.fallback(e, data, target, p)
+		return nil
+	}
+
+	switch s := s.(type) {
+	case Object:
+		if t.Kind() == reflect.Struct {
+			return g.genStruct(s, e, data, target, t, p)
+		}
+	case Array:
+		if t.Kind() == reflect.Slice {
+			return g.genSlice(s, e, data, target, t, p)
+		}
+	case Map:
+		if t.Kind() == reflect.Map && t.Key().Kind() == reflect.String &&
+			t.Elem().Kind() != reflect.Ptr {
+			return g.genMap(s, e, data, target, t, p)
+		}
+	case String, StringEnum:
+		if t.Kind() != reflect.String {
+			return mismatch(s, t, p)
+		}
+		return g.assign(target, t, data+".(string)", "string")
+	case Boolean:
+		if t.Kind() != reflect.Bool {
+			return mismatch(s, t, p)
+		}
+		return g.assign(target, t, data+".(bool)", "bool")
+	case Integer:
+		return g.genInteger(s, s.Minimum, s.Maximum, e, data, target, t, p)
+	case IntegerEnum:
+		min, max := s.bounds()
+		return g.genInteger(s, min, max, e, data, target, t, p)
+	case Number:
+		// Integer types need the checks done by Number.Map
+		if t.Kind() != reflect.Float64 && t.Kind() != reflect.Float32 {
+			break
+		}
+		g.helpers["Number"] = true
+		value := fmt.Sprintf("map%sNumber(%s)", g.name, data)
+		if t.Kind() == reflect.Float64 || s.Minimum >= -math.MaxFloat32 && s.Maximum <= math.MaxFloat32 {
+			return g.assign(target, t, value, "float64")
+		}
+
+		// Numbers beyond the range of float32 are checked like Number.Map does
+		f := g.variable("f")
+		g.imports["fmt"] = true
+		g.imports["math"] = true
+		g.imports["reflect"] = true
+		g.printf("if %s := %s; math.Abs(%s) > math.MaxFloat32 {\n", f, value, f)
+		g.printf("return &%s{Path: %s, SchemaType: \"Number\", TargetType: reflect.TypeOf(%s), "+
+			"Reason: fmt.Sprintf(\"%%g overflows float32\", %s)}\n",
+			g.qualify("TypeMismatchError"), p, target, f)
+		g.printf("} else {\n")
+		if err := g.assign(target, t, f, "float64"); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	}
+	g.fallback(e, data, target, p)
+	return nil
+}
+
+// fallback writes code for mapping data into target with the Map method of the
+// schema at e.
+func (g *generator) fallback(e schemaExpr, data, target string, p pathExpr) {
+	g.printf("if err := %s.Map(%s, %s); err != nil {\n", e.expr, data, addressOf(target))
+	g.returnError("err", p)
+	g.printf("}\n")
+}
+
+// assign writes code assigning value of the type named valueType to target of
+// type t, converting value if t is another type.
+func (g *generator) assign(target string, t reflect.Type, value, valueType string) error {
+	typeName, err := g.typeExpr(t)
+	if err != nil {
+		return err
+	}
+	if typeName != valueType {
+		value = typeName + "(" + value + ")"
+	}
+	g.printf("%s = %s\n", assignable(target), value)
+	return nil
+}
+
+func (g *generator) genStruct(s Object, e schemaExpr, data, target string, t reflect.Type, p pathExpr) error {
+	plan := planFor(t)
+	if plan.reason != "" {
+		e := mismatch(s, t, p)
+		e.Reason = plan.reason
+		return e
+	}
+	if key, ok := plan.missing(s); ok {
+		e := mismatch(s, t, p.literal(formatKeyPath(key)))
+		e.Reason = "there is no field for the property"
+		return e
+	}
+	if plan.remain != nil {
+		// Additional properties for the remain field are collected at runtime
+		g.fallback(e, data, target, p)
+		return nil
+	}
+
+	d, v := g.variable("d"), g.variable("v")
+	g.printf("%s := %s.(map[string]interface{})\n", d, data)
+	for _, key := range sortedKeys(s.Properties) {
+		ps := s.Properties[key]
+		if ps == nil {
+			continue
+		}
+		f, _ := plan.field(key)
+		fp := p.literal(formatKeyPath(key))
+		g.printf("if %s, ok := %s[%s]; ok {\n", v, d, strconv.Quote(key))
+
+		// Find the field, allocating embedded structs as needed
+		field := target
+		ft := t
+		for i, x := range f.index {
+			if i > 0 && ft.Kind() == reflect.Ptr {
+				if ft.Elem().Name() == "" || !isExported(ft.Elem().Name()) {
+					return mismatch(s, t, fp)
+				}
+				g.printf("if %s == nil {\n%s = new(%s)\n}\n", field, field, g.qualifyType(ft.Elem()))
+				ft = ft.Elem()
+			}
+			field = fieldOf(field, ft.Field(x).Name)
+			ft = ft.Field(x).Type
+		}
+
+		if f.typ == typeOfEmptyInterface {
+			g.printf("%s = %s\n", field, v)
+			g.printf("}\n")
+			continue
+		}
+		if f.alloc {
+			typeName, err := g.typeExpr(f.typ.Elem())
+			if err != nil {
+				return err
+			}
+			g.printf("%s = new(%s)\n", field, typeName)
+			field, ft = "(*"+field+")", f.typ.Elem()
+		}
+
+		// Values with the string option are parsed from their JSON string
+		quoted := f.quoted && ft.Kind() != reflect.String
+		if quoted {
+			str := g.variable("s")
+			g.imports["encoding/json"] = true
+			g.imports["reflect"] = true
+			g.printf("if %s, ok := %s.(string); ok {\n", str, v)
+			g.printf("if json.Unmarshal([]byte(%s), %s) != nil {\n", str, addressOf(field))
+			g.printf("return &%s{Path: %s, SchemaType: %s, TargetType: reflect.TypeOf(%s)}\n",
+				g.qualify("TypeMismatchError"), fp,
+				strconv.Quote(reflect.TypeOf(ps).Name()), field)
+			g.printf("}\n} else {\n")
+		}
+		fe := g.field(e, "Object", "Properties["+strconv.Quote(key)+"]")
+		n := g.code.Len()
+		if err := g.gen(ps, fe, v, field, ft, fp); err != nil {
+			// Other values may not be mapped at all, if the schema is a String
+			if _, ok := err.(*TypeMismatchError); !ok || !quoted {
+				return err
+			}
+			g.code.Truncate(n)
+			g.fallback(fe, v, field, fp)
+		}
+		if quoted {
+			g.printf("}\n")
+		}
+		g.printf("}\n")
+	}
+	return nil
+}
+
+func (g *generator) genSlice(s Array, e schemaExpr, data, target string, t reflect.Type, p pathExpr) error {
+	typeName, err := g.typeExpr(t)
+	if err != nil {
+		return err
+	}
+	items, i, v := g.variable("s"), g.variable("i"), g.variable("v")
+
+	// Validate accepts any slice, so other slices are mapped with reflection
+	g.printf("if %s, ok := %s.([]interface{}); ok {\n", items, data)
+	g.printf("%s = make(%s, len(%s))\n", assignable(target), typeName, items)
+	g.printf("for %s, %s := range %s {\n", i, v, items)
+	g.imports["strconv"] = true
+	ip := p.literal("[").expr("strconv.Itoa(" + i + ")").literal("]")
+	err = g.gen(s.Items, g.field(e, "Array", "Items"), v, target+"["+i+"]", t.Elem(), ip)
+	if err != nil {
+		return err
+	}
+	g.printf("}\n} else ")
+	g.fallback(e, data, target, p)
+	return nil
+}
+
+func (g *generator) genMap(s Map, e schemaExpr, data, target string, t reflect.Type, p pathExpr) error {
+	typeName, err := g.typeExpr(t)
+	if err != nil {
+		return err
+	}
+	values, k, v := g.variable("m"), g.variable("k"), g.variable("v")
+	g.printf("%s := %s.(map[string]interface{})\n", values, data)
+	g.printf("%s = make(%s, len(%s))\n", assignable(target), typeName, values)
+	g.printf("for %s, %s := range %s {\n", k, v, values)
+
+	key := k
+	if t.Key().PkgPath() != "" {
+		keyType, err := g.typeExpr(t.Key())
+		if err != nil {
+			return err
+		}
+		key = keyType + "(" + k + ")"
+	}
+	if t.Elem() == typeOfEmptyInterface {
+		g.printf("%s[%s] = %s\n}\n", target, key, v)
+		return nil
+	}
+
+	elemType, err := g.typeExpr(t.Elem())
+	if err != nil {
+		return err
+	}
+	x := g.variable("x")
+	g.printf("var %s %s\n", x, elemType)
+	vp := p.expr(g.qualify("KeyPath") + "(" + k + ")")
+	if err := g.gen(s.Values, g.field(e, "Map", "Values"), v, x, t.Elem(), vp); err != nil {
+		return err
+	}
+	g.printf("%s[%s] = %s\n}\n", target, key, x)
+	return nil
+}
+
+func (g *generator) genInteger(s Schema, min, max int64, e schemaExpr, data, target string, t reflect.Type, p pathExpr) error {
+	if !integerFits(t.Kind(), min, max) {
+		return mismatch(s, t, p)
+	}
+
+	// Validate accepts any integer type, so named types are mapped with
+	// reflection
+	x := g.variable("x")
+	g.helpers["Integer"] = true
+	g.printf("if %s, ok := map%sInteger(%s); ok {\n", x, g.name, data)
+	if err := g.assign(target, t, x, "int64"); err != nil {
+		return err
+	}
+	g.printf("} else ")
+	g.fallback(e, data, target, p)
+	return nil
+}
+
+// writeHelpers writes the helper functions used by the generated code.
+func (g *generator) writeHelpers(b *bytes.Buffer) {
+	if g.helpers["Integer"] {
+		g.imports["encoding/json"] = true
+		fmt.Fprintf(b, "\n// map%sInteger returns data as int64, if data has a builtin type.\n", g.name)
+		fmt.Fprintf(b, "func map%sInteger(data interface{}) (int64, bool) {\n", g.name)
+		b.WriteString("switch v := data.(type) {\n")
+		b.WriteString("case json.Number:\nif i, err := v.Int64(); err == nil {\nreturn i, true\n}\n")
+		b.WriteString("f, _ := v.Float64()\nreturn int64(f), true\n")
+		for _, kind := range []string{
+			"float64", "float32", "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64",
+		} {
+			fmt.Fprintf(b, "case %s:\nreturn int64(v), true\n", kind)
+		}
+		b.WriteString("}\nreturn 0, false\n}\n")
+	}
+	if g.helpers["Number"] {
+		g.imports["encoding/json"] = true
+		fmt.Fprintf(b, "\n// map%sNumber returns data, which is a float64 or json.Number, as float64.\n", g.name)
+		fmt.Fprintf(b, "func map%sNumber(data interface{}) float64 {\n", g.name)
+		b.WriteString("if n, ok := data.(json.Number); ok {\nf, _ := n.Float64()\nreturn f\n}\n")
+		b.WriteString("return data.(float64)\n}\n")
+	}
+}
+
+// isExported returns true, if name is an exported identifier.
+func isExported(name string) bool {
+	return name != "" && strings.ToUpper(name[:1]) == name[:1]
+}
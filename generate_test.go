@@ -0,0 +1,370 @@
+package schematypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+//go:generate go test -run TestGenerate$ -update
+
+var update = flag.Bool("update", false, "update generated_test.go")
+
+var generateTestSchema = Object{
+	Properties: Properties{
+		"name":     String{},
+		"kind":     StringEnum{Options: []string{"a", "b"}},
+		"size":     Integer{Minimum: 0, Maximum: 100},
+		"count":    String{Pattern: "^[0-9]+$"},
+		"level":    IntegerEnum{Options: []int{1, 2, 3}},
+		"ratio":    Number{Minimum: 0, Maximum: 1},
+		"weight":   Number{Minimum: -math.MaxFloat64, Maximum: math.MaxFloat64},
+		"enabled":  Boolean{},
+		"created":  DateTime{},
+		"extra":    Object{AdditionalProperties: true},
+		"tags":     Array{Items: String{}},
+		"limits":   Map{Values: Integer{Minimum: 0, Maximum: 10}},
+		"scores":   Map{Values: Number{Minimum: -math.MaxFloat64, Maximum: math.MaxFloat64}},
+		"checksum": String{},
+		"owner": Object{
+			Properties: Properties{"id": String{}},
+			Required:   []string{"id"},
+		},
+		"steps": Array{Items: Object{
+			Properties: Properties{
+				"command": Array{Items: String{}},
+				"retries": Integer{Minimum: 0, Maximum: 5},
+				"label":   String{},
+			},
+		}},
+	},
+	Required: []string{"name"},
+}
+
+type generateTestKind string
+
+type generateTestOwner struct {
+	ID string `json:"id"`
+}
+
+type generateTestOtherOwner struct {
+	ID int `json:"id"`
+}
+
+type generateTestOtherStruct struct {
+	Owner *generateTestOtherOwner `json:"owner"`
+}
+
+type generateTestLabel string
+
+func (l *generateTestLabel) UnmarshalText(text []byte) error {
+	if string(text) == "bad" {
+		return errors.New("bad label")
+	}
+	*l = generateTestLabel(text)
+	return nil
+}
+
+type generateTestStep struct {
+	Command []string          `json:"command"`
+	Retries int               `json:"retries"`
+	Label   generateTestLabel `json:"label"`
+}
+
+type generateTestBase struct {
+	Checksum string `json:"checksum"`
+}
+
+type generateTestStruct struct {
+	generateTestBase
+	Name    string                 `json:"name"`
+	Kind    generateTestKind       `json:"kind"`
+	Size    *int                   `json:"size"`
+	Count   uint16                 `json:"count,string"`
+	Level   uint8                  `json:"level"`
+	Ratio   float32                `json:"ratio"`
+	Weight  float32                `json:"weight"`
+	Enabled bool                   `json:"enabled"`
+	Created time.Time              `json:"created"`
+	Extra   interface{}            `json:"extra"`
+	Tags    []string               `json:"tags"`
+	Limits  map[string]int         `json:"limits"`
+	Scores  map[string]float32     `json:"scores"`
+	Owner   *generateTestOwner     `json:"owner"`
+	Steps   []generateTestStep     `json:"steps"`
+	Other   map[string]interface{} `json:"-"`
+}
+
+var generateTestOptions = GenerateOptions{
+	Package:    "schematypes",
+	Name:       "GenerateTest",
+	Schema:     generateTestSchema,
+	SchemaExpr: "generateTestSchema",
+	Target:     generateTestStruct{},
+}
+
+func TestGenerate(t *testing.T) {
+	var b bytes.Buffer
+	nilOrPanic(Generate(&b, generateTestOptions), "Expected Generate to succeed")
+	if *update {
+		nilOrPanic(ioutil.WriteFile("generated_test.go", b.Bytes(), 0644), "Failed to write generated_test.go")
+	}
+	golden, err := ioutil.ReadFile("generated_test.go")
+	nilOrPanic(err, "Failed to read generated_test.go")
+	assert(bytes.Equal(b.Bytes(), golden),
+		"Generated code differs from generated_test.go, run go generate:\n", b.String())
+}
+
+func TestGenerateMismatch(t *testing.T) {
+	options := generateTestOptions
+	options.Target = generateTestStep{}
+	err := Generate(ioutil.Discard, options)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+
+	options.Target = generateTestOtherStruct{}
+	options.Schema = Object{Properties: Properties{
+		"owner": generateTestSchema.Properties["owner"],
+	}}
+	err = Generate(ioutil.Discard, options)
+	e, ok := err.(*TypeCheckError)
+	assert(ok, "Expected a TypeCheckError, got: ", err)
+	assert(e.Mismatches[0].Path == ".owner.id", "Unexpected path: ", e.Mismatches[0].Path)
+	assert(e.Mismatches[0].SchemaType == "String", "Unexpected schema type: ", e.Mismatches[0].SchemaType)
+}
+
+// generateTestModule is a module with types from packages whose names differ
+// from the last element of their path, and a package named like one of the
+// packages imported by the generated code.
+var generateTestModule = map[string]string{
+	"go.mod": `module example.com/gen
+
+go 1.20
+
+require github.com/taskcluster/go-schematypes v0.0.0
+
+replace github.com/taskcluster/go-schematypes => REPO
+`,
+	"go-types/types.go": `package types
+
+type Owner struct {
+	ID string ` + "`json:\"id\"`" + `
+}
+`,
+	"v2/kinds.go": `package kinds
+
+type Kind string
+`,
+	"units/units.go": `package math
+
+type Meters float32
+`,
+	"config/config.go": `package config
+
+import (
+	schematypes "github.com/taskcluster/go-schematypes"
+	"math"
+
+	"example.com/gen/go-types"
+	"example.com/gen/v2"
+	units "example.com/gen/units"
+)
+
+var Schema = schematypes.Object{
+	Properties: schematypes.Properties{
+		"kind":   schematypes.StringEnum{Options: []string{"a", "b"}},
+		"owner":  schematypes.Object{Properties: schematypes.Properties{"id": schematypes.String{}}},
+		"owners": schematypes.Array{Items: schematypes.Object{Properties: schematypes.Properties{"id": schematypes.String{}}}},
+		"height": schematypes.Number{Minimum: -math.MaxFloat64, Maximum: math.MaxFloat64},
+	},
+}
+
+type Config struct {
+	Kind   kinds.Kind     ` + "`json:\"kind\"`" + `
+	Owner  *types.Owner   ` + "`json:\"owner\"`" + `
+	Owners []types.Owner  ` + "`json:\"owners\"`" + `
+	Height units.Meters   ` + "`json:\"height\"`" + `
+}
+`,
+	"gen/main.go": `package main
+
+import (
+	"os"
+
+	schematypes "github.com/taskcluster/go-schematypes"
+
+	"example.com/gen/config"
+)
+
+func main() {
+	f, err := os.Create("config/config_mapper.go")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	err = schematypes.Generate(f, schematypes.GenerateOptions{
+		Package:    "config",
+		Name:       "Config",
+		Schema:     config.Schema,
+		SchemaExpr: "Schema",
+		Target:     config.Config{},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+`,
+	"check/main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/gen/config"
+)
+
+func main() {
+	c, err := config.MapConfig(map[string]interface{}{
+		"kind":   "b",
+		"owner":  map[string]interface{}{"id": "x"},
+		"owners": []interface{}{map[string]interface{}{"id": "y"}},
+		"height": 1.5,
+	})
+	fmt.Println(c.Kind, c.Owner.ID, c.Owners[0].ID, c.Height, err)
+}
+`,
+}
+
+func TestGenerateExternalPackages(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping test running the go tool in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("Skipping test, the go tool is not available")
+	}
+	repo, err := os.Getwd()
+	nilOrPanic(err, "Failed to find the working directory")
+	sums, err := ioutil.ReadFile("go.sum")
+	nilOrPanic(err, "Failed to read go.sum")
+
+	dir := t.TempDir()
+	files := map[string]string{"go.sum": string(sums)}
+	for name, content := range generateTestModule {
+		files[name] = strings.Replace(content, "REPO", repo, 1)
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		nilOrPanic(os.MkdirAll(filepath.Dir(name), 0755), "Failed to create directory")
+		nilOrPanic(ioutil.WriteFile(name, []byte(content), 0644), "Failed to write ", name)
+	}
+
+	run := func(args ...string) string {
+		cmd := exec.Command(goTool, args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		assert(err == nil, "Failed to run go ", args, ": ", string(output))
+		return string(output)
+	}
+	run("run", "./gen")
+	run("vet", "./...")
+	output := run("run", "./check")
+	assert(output == "b x y 1.5 <nil>\n", "Unexpected output: ", output)
+
+	mapper, err := ioutil.ReadFile(filepath.Join(dir, "config", "config_mapper.go"))
+	nilOrPanic(err, "Failed to read the generated code")
+	for _, spec := range []string{`kinds "example.com/gen/v2"`, `math2 "example.com/gen/units"`,
+		`"example.com/gen/go-types"`} {
+		assert(bytes.Contains(mapper, []byte(spec)), "Expected import ", spec, " in:\n", string(mapper))
+	}
+}
+
+func TestGeneratedMap(t *testing.T) {
+	var data interface{}
+	nilOrPanic(json.Unmarshal([]byte(`{
+		"name": "test",
+		"kind": "b",
+		"size": 7,
+		"count": "500",
+		"level": 2,
+		"ratio": 0.5,
+		"weight": -2.5,
+		"enabled": true,
+		"created": "2020-01-02T03:04:05Z",
+		"extra": {"key": [1]},
+		"tags": ["x", "y"],
+		"limits": {"cpu": 2},
+		"scores": {"a": 1, "a b": 2},
+		"checksum": "abc",
+		"owner": {"id": "me"},
+		"steps": [{"command": ["ls"], "retries": 1, "label": "list"}, {}]
+	}`), &data), "Internal test error")
+
+	result, err := MapGenerateTest(data)
+	nilOrPanic(err, "Expected MapGenerateTest to succeed")
+	var expected generateTestStruct
+	nilOrPanic(generateTestSchema.Map(data, &expected), "Expected Map to succeed")
+	assert(reflect.DeepEqual(result, expected), "Expected the result of Map, got: ", result)
+
+	// Errors are the same as from Map
+	data.(map[string]interface{})["count"] = "70000"
+	_, err = MapGenerateTest(data)
+	var e *TypeMismatchError
+	assert(errors.As(err, &e), "Expected a type mismatch, got: ", err)
+	assert(e.Path == ".count", "Unexpected path: ", e.Path)
+	assert(err.Error() == generateTestSchema.Map(data, &expected).Error(), "Expected the error from Map")
+
+	// Numbers are checked for overflow, like Map does
+	data.(map[string]interface{})["count"] = "500"
+	data.(map[string]interface{})["weight"] = 1e300
+	_, err = MapGenerateTest(data)
+	assert(errors.As(err, &e), "Expected a type mismatch, got: ", err)
+	assert(e.Path == ".weight", "Unexpected path: ", e.Path)
+	assert(err.Error() == generateTestSchema.Map(data, &expected).Error(),
+		"Expected the error from Map, got: ", err)
+
+	// Paths to values in maps are the same as from Map
+	data.(map[string]interface{})["weight"] = 1.5
+	for key, path := range map[string]string{"a": ".scores.a", "a b": `.scores["a b"]`} {
+		data.(map[string]interface{})["scores"] = map[string]interface{}{key: 1e300}
+		_, err = MapGenerateTest(data)
+		assert(errors.As(err, &e), "Expected a type mismatch, got: ", err)
+		assert(e.Path == path, "Unexpected path: ", e.Path)
+		assert(err.Error() == generateTestSchema.Map(data, &expected).Error(),
+			"Expected the error from Map, got: ", err)
+	}
+
+	// Errors from hooks are reported at the path of the field, like Map does
+	data.(map[string]interface{})["scores"] = map[string]interface{}{}
+	data.(map[string]interface{})["steps"] = []interface{}{
+		map[string]interface{}{}, map[string]interface{}{"label": "bad"},
+	}
+	_, err = MapGenerateTest(data)
+	var ve *ValidationError
+	assert(errors.As(err, &ve), "Expected a ValidationError, got: ", err)
+	assert(ve.Issues("")[0].Path() == "root.steps[1].label", "Unexpected path: ", ve.Issues("")[0].Path())
+	assert(err.Error() == generateTestSchema.Map(data, &expected).Error(),
+		"Expected the error from Map, got: ", err)
+
+	delete(data.(map[string]interface{}), "name")
+	err = ValidateGenerateTest(data)
+	assert(err != nil && err.Error() == generateTestSchema.Validate(data).Error(),
+		"Expected the error from Validate, got: ", err)
+}
+
+func BenchmarkGeneratedMap(b *testing.B) {
+	data := planTestData()
+	for i := 0; i < b.N; i++ {
+		if _, err := MapGenerateTest(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
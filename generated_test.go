@@ -0,0 +1,209 @@
+// Code generated by schematypes.Generate. DO NOT EDIT.
+
+package schematypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// ValidateGenerateTest validates data against generateTestSchema, this returns nil if data
+// satisfies the schema, otherwise it returns a ValidationError.
+func ValidateGenerateTest(data interface{}) error {
+	return generateTestSchema.Validate(data)
+}
+
+// MapGenerateTest validates data against generateTestSchema and maps it into a generateTestStruct.
+func MapGenerateTest(data interface{}) (generateTestStruct, error) {
+	var result generateTestStruct
+	if err := ValidateGenerateTest(data); err != nil {
+		return result, err
+	}
+	err := mapGenerateTest(data, &result)
+	return result, err
+}
+
+func mapGenerateTest(data interface{}, result *generateTestStruct) error {
+	d1 := data.(map[string]interface{})
+	if v2, ok := d1["checksum"]; ok {
+		result.generateTestBase.Checksum = v2.(string)
+	}
+	if v2, ok := d1["count"]; ok {
+		if s3, ok := v2.(string); ok {
+			if json.Unmarshal([]byte(s3), &result.Count) != nil {
+				return &TypeMismatchError{Path: ".count", SchemaType: "String", TargetType: reflect.TypeOf(result.Count)}
+			}
+		} else {
+			if err := generateTestSchema.Properties["count"].Map(v2, &result.Count); err != nil {
+				return PrefixError(err, ".count")
+			}
+		}
+	}
+	if v2, ok := d1["created"]; ok {
+		if err := generateTestSchema.Properties["created"].Map(v2, &result.Created); err != nil {
+			return PrefixError(err, ".created")
+		}
+	}
+	if v2, ok := d1["enabled"]; ok {
+		result.Enabled = v2.(bool)
+	}
+	if v2, ok := d1["extra"]; ok {
+		result.Extra = v2
+	}
+	if v2, ok := d1["kind"]; ok {
+		result.Kind = generateTestKind(v2.(string))
+	}
+	if v2, ok := d1["level"]; ok {
+		if x4, ok := mapGenerateTestInteger(v2); ok {
+			result.Level = uint8(x4)
+		} else if err := generateTestSchema.Properties["level"].Map(v2, &result.Level); err != nil {
+			return PrefixError(err, ".level")
+		}
+	}
+	if v2, ok := d1["limits"]; ok {
+		m5 := v2.(map[string]interface{})
+		result.Limits = make(map[string]int, len(m5))
+		for k6, v7 := range m5 {
+			var x8 int
+			if x9, ok := mapGenerateTestInteger(v7); ok {
+				x8 = int(x9)
+			} else if err := generateTestSchema.Properties["limits"].(Map).Values.Map(v7, &x8); err != nil {
+				return PrefixError(err, ".limits"+KeyPath(k6))
+			}
+			result.Limits[k6] = x8
+		}
+	}
+	if v2, ok := d1["name"]; ok {
+		result.Name = v2.(string)
+	}
+	if v2, ok := d1["owner"]; ok {
+		result.Owner = new(generateTestOwner)
+		d10 := v2.(map[string]interface{})
+		if v11, ok := d10["id"]; ok {
+			result.Owner.ID = v11.(string)
+		}
+	}
+	if v2, ok := d1["ratio"]; ok {
+		result.Ratio = float32(mapGenerateTestNumber(v2))
+	}
+	if v2, ok := d1["scores"]; ok {
+		m12 := v2.(map[string]interface{})
+		result.Scores = make(map[string]float32, len(m12))
+		for k13, v14 := range m12 {
+			var x15 float32
+			if f16 := mapGenerateTestNumber(v14); math.Abs(f16) > math.MaxFloat32 {
+				return &TypeMismatchError{Path: ".scores" + KeyPath(k13), SchemaType: "Number", TargetType: reflect.TypeOf(x15), Reason: fmt.Sprintf("%g overflows float32", f16)}
+			} else {
+				x15 = float32(f16)
+			}
+			result.Scores[k13] = x15
+		}
+	}
+	if v2, ok := d1["size"]; ok {
+		result.Size = new(int)
+		if x17, ok := mapGenerateTestInteger(v2); ok {
+			*result.Size = int(x17)
+		} else if err := generateTestSchema.Properties["size"].Map(v2, result.Size); err != nil {
+			return PrefixError(err, ".size")
+		}
+	}
+	if v2, ok := d1["steps"]; ok {
+		if s18, ok := v2.([]interface{}); ok {
+			result.Steps = make([]generateTestStep, len(s18))
+			for i19, v20 := range s18 {
+				d21 := v20.(map[string]interface{})
+				if v22, ok := d21["command"]; ok {
+					if s23, ok := v22.([]interface{}); ok {
+						result.Steps[i19].Command = make([]string, len(s23))
+						for i24, v25 := range s23 {
+							result.Steps[i19].Command[i24] = v25.(string)
+						}
+					} else if err := generateTestSchema.Properties["steps"].(Array).Items.(Object).Properties["command"].Map(v22, &result.Steps[i19].Command); err != nil {
+						return PrefixError(err, ".steps["+strconv.Itoa(i19)+"].command")
+					}
+				}
+				if v22, ok := d21["label"]; ok {
+					if err := generateTestSchema.Properties["steps"].(Array).Items.(Object).Properties["label"].Map(v22, &result.Steps[i19].Label); err != nil {
+						return PrefixError(err, ".steps["+strconv.Itoa(i19)+"].label")
+					}
+				}
+				if v22, ok := d21["retries"]; ok {
+					if x26, ok := mapGenerateTestInteger(v22); ok {
+						result.Steps[i19].Retries = int(x26)
+					} else if err := generateTestSchema.Properties["steps"].(Array).Items.(Object).Properties["retries"].Map(v22, &result.Steps[i19].Retries); err != nil {
+						return PrefixError(err, ".steps["+strconv.Itoa(i19)+"].retries")
+					}
+				}
+			}
+		} else if err := generateTestSchema.Properties["steps"].Map(v2, &result.Steps); err != nil {
+			return PrefixError(err, ".steps")
+		}
+	}
+	if v2, ok := d1["tags"]; ok {
+		if s27, ok := v2.([]interface{}); ok {
+			result.Tags = make([]string, len(s27))
+			for i28, v29 := range s27 {
+				result.Tags[i28] = v29.(string)
+			}
+		} else if err := generateTestSchema.Properties["tags"].Map(v2, &result.Tags); err != nil {
+			return PrefixError(err, ".tags")
+		}
+	}
+	if v2, ok := d1["weight"]; ok {
+		if f30 := mapGenerateTestNumber(v2); math.Abs(f30) > math.MaxFloat32 {
+			return &TypeMismatchError{Path: ".weight", SchemaType: "Number", TargetType: reflect.TypeOf(result.Weight), Reason: fmt.Sprintf("%g overflows float32", f30)}
+		} else {
+			result.Weight = float32(f30)
+		}
+	}
+	return nil
+}
+
+// mapGenerateTestInteger returns data as int64, if data has a builtin type.
+func mapGenerateTestInteger(data interface{}) (int64, bool) {
+	switch v := data.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, true
+		}
+		f, _ := v.Float64()
+		return int64(f), true
+	case float64:
+		return int64(v), true
+	case float32:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return int64(v), true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// mapGenerateTestNumber returns data, which is a float64 or json.Number, as float64.
+func mapGenerateTestNumber(data interface{}) float64 {
+	if n, ok := data.(json.Number); ok {
+		f, _ := n.Float64()
+		return f
+	}
+	return data.(float64)
+}
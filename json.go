@@ -0,0 +1,278 @@
+package schematypes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateJSON parses data as JSON and validates it against schema, numbers
+// are parsed as json.Number to preserve precision. This returns nil if data
+// satisfies schema, otherwise it returns a ValidationError instance.
+//
+// Issues in the ValidationError have the Position of the value in data they
+// are for, if data isn't valid JSON there is an issue for the syntax error.
+func ValidateJSON(schema Schema, data []byte) error {
+	value, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+	return addPositions(schema.Validate(value), data)
+}
+
+// MapJSON parses data as JSON, validates it against schema and maps it into
+// target. Syntax errors are reported like ValidateJSON does.
+func MapJSON(schema Schema, data []byte, target interface{}) error {
+	value, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+	return addPositions(schema.Map(value, target), data)
+}
+
+// Decode reads a JSON value from r, validates it against schema and maps it
+// into target. Syntax errors are reported like ValidateJSON does, errors
+// reading from r are returned as is, and io.EOF is returned if r is empty.
+//
+// The value must be the only value in r, as Decode may read data from r
+// beyond the value, which is discarded. Use a Decoder to read a stream of
+// values.
+func Decode(schema Schema, r io.Reader, target interface{}) error {
+	return NewDecoder(schema, r).Decode(target)
+}
+
+// A Decoder reads a stream of JSON values, such as newline-delimited JSON,
+// validating each value against a schema and mapping it into a target.
+type Decoder struct {
+	schema Schema
+	d      *json.Decoder
+}
+
+// NewDecoder returns a Decoder reading values for schema from r. Like
+// json.Decoder the Decoder buffers data from r, so it may read data from r
+// beyond the values decoded.
+func NewDecoder(schema Schema, r io.Reader) *Decoder {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return &Decoder{schema: schema, d: d}
+}
+
+// Decode reads the next JSON value, validates it and maps it into target, like
+// the Decode function. This returns io.EOF when there are no more values.
+func (d *Decoder) Decode(target interface{}) error {
+	value, err := decodeJSON(d.d)
+	if err != nil {
+		return err
+	}
+	return d.schema.Map(value, target)
+}
+
+// parseJSON returns the JSON value in data, which must not be followed by
+// anything but whitespace.
+func parseJSON(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	value, err := decodeJSON(d)
+	if err == io.EOF {
+		err = syntaxIssue(int64(len(data)), "unexpected end of JSON input")
+	}
+	if err != nil {
+		return nil, addPositions(err, data)
+	}
+	rest := bytes.TrimLeft(data[d.InputOffset():], " \t\r\n")
+	if len(rest) > 0 {
+		return nil, addPositions(syntaxIssue(
+			int64(len(data)-len(rest)), "unexpected data after top-level value",
+		), data)
+	}
+	return value, nil
+}
+
+// decodeJSON returns the next JSON value from d, or io.EOF if there are no
+// more values.
+func decodeJSON(d *json.Decoder) (interface{}, error) {
+	var value interface{}
+	err := d.Decode(&value)
+	if e, ok := err.(*json.SyntaxError); ok {
+		return nil, syntaxIssue(e.Offset, e.Error())
+	}
+	if err == io.ErrUnexpectedEOF {
+		// The decoder has buffered all the input, so the end is after that
+		rest, _ := io.Copy(io.Discard, d.Buffered())
+		return nil, syntaxIssue(d.InputOffset()+rest, "unexpected end of JSON input")
+	}
+	return value, err
+}
+
+// syntaxIssue returns a ValidationError with an issue for a syntax error at
+// offset.
+func syntaxIssue(offset int64, reason string) *ValidationError {
+	e := singleIssue("", "Invalid JSON at offset %d: %s", offset, reason)
+	e.issues[0].position = &Position{Offset: offset}
+	return e
+}
+
+// addPositions sets the position of the issues in err, if err is a
+// ValidationError from validating the JSON text in data.
+func addPositions(err error, data []byte) error {
+	e, ok := err.(*ValidationError)
+	if !ok {
+		return err
+	}
+	var offsets map[string]int64
+	for _, issue := range e.issues {
+		if issue.position == nil {
+			offsets = valueOffsets(data)
+			break
+		}
+	}
+	for i := range e.issues {
+		setPosition(&e.issues[i], offsets, data)
+	}
+	return e
+}
+
+// setPosition sets the position of issue and its causes, using the offsets
+// of values in data by JSON pointer.
+func setPosition(issue *ValidationIssue, offsets map[string]int64, data []byte) {
+	if issue.position == nil {
+		// Find the value, or the closest parent if the value is missing
+		pointer := issue.InstanceLocation()
+		offset, ok := offsets[pointer]
+		for !ok && pointer != "" {
+			pointer = pointer[:strings.LastIndex(pointer, "/")]
+			offset, ok = offsets[pointer]
+		}
+		issue.position = &Position{Offset: offset}
+	}
+	p := issue.position
+	p.Line = 1 + bytes.Count(data[:p.Offset], []byte("\n"))
+	p.Column = int(p.Offset) - bytes.LastIndexByte(data[:p.Offset], '\n')
+
+	for i := range issue.causes {
+		setPosition(&issue.causes[i], offsets, data)
+	}
+}
+
+// valueOffsets returns the offsets of the values in the JSON text data by
+// JSON pointer, data must be valid JSON.
+func valueOffsets(data []byte) map[string]int64 {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	offsets := make(map[string]int64)
+
+	var visit func(pointer string)
+	visit = func(pointer string) {
+		// Skip separators to find the start of the value
+		offset := d.InputOffset()
+		for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[offset]) != -1 {
+			offset++
+		}
+		offsets[pointer] = offset
+
+		token, err := d.Token()
+		if err != nil {
+			return
+		}
+		switch token {
+		case json.Delim('{'):
+			for d.More() {
+				key, err := d.Token()
+				if err != nil {
+					return
+				}
+				visit(pointer + "/" + escapePointerToken(key.(string)))
+			}
+			d.Token()
+		case json.Delim('['):
+			for i := 0; d.More(); i++ {
+				visit(pointer + "/" + strconv.Itoa(i))
+			}
+			d.Token()
+		}
+	}
+	visit("")
+	return offsets
+}
+
+// Render returns the issues in e as human readable text for the JSON text
+// source, like compiler errors with a snippet of the source, on the form:
+//
+//	String 'long' at root.name is longer than maximum 3 length allowed
+//	 --> 3:11
+//	  |
+//	3 |   "name": "long",
+//	  |           ^
+//
+// Issues without a position are rendered as their message only.
+func (e *ValidationError) Render(source []byte) string {
+	var b strings.Builder
+	for i, issue := range e.Issues("") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(issue.String())
+		b.WriteString("\n")
+		if p, ok := issue.Position(); ok && p.Line > 0 {
+			b.WriteString(renderSnippet(source, p))
+		}
+	}
+	return b.String()
+}
+
+// snippetWidth is the number of bytes shown on each side of the position in
+// snippets of long lines.
+const snippetWidth = 40
+
+// renderSnippet returns the line in source with position p and a caret
+// pointing at the position.
+func renderSnippet(source []byte, p Position) string {
+	// Find the line and the column in it
+	start := int(p.Offset) - (p.Column - 1)
+	end := bytes.IndexByte(source[start:], '\n')
+	if end == -1 {
+		end = len(source)
+	} else {
+		end += start
+	}
+	line := bytes.TrimRight(source[start:end], "\r")
+	column := p.Column - 1
+
+	// Cut long lines to a window around the column
+	prefix, suffix := "", ""
+	if column > snippetWidth {
+		cut := column - snippetWidth
+		for cut < column && !utf8.RuneStart(line[cut]) {
+			cut++
+		}
+		line, column, prefix = line[cut:], column-cut, "..."
+	}
+	if len(line) > column+snippetWidth {
+		cut := column + snippetWidth
+		for cut > column && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		line, suffix = line[:cut], "..."
+	}
+
+	// Indent the caret, keeping tabs to align with the line
+	var indent strings.Builder
+	indent.WriteString(strings.Repeat(" ", len(prefix)))
+	for _, r := range string(line[:column]) {
+		if r == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+	}
+
+	number := strconv.Itoa(p.Line)
+	gutter := strings.Repeat(" ", len(number))
+	return gutter + "--> " + p.String() + "\n" +
+		gutter + " |\n" +
+		number + " | " + prefix + string(line) + suffix + "\n" +
+		gutter + " | " + indent.String() + "^\n"
+}
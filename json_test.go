@@ -0,0 +1,169 @@
+package schematypes
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+var jsonTestSchema = Object{
+	Properties: Properties{
+		"id":      Integer{Minimum: 0, Maximum: 1<<63 - 1},
+		"ratio":   Number{Minimum: 0, Maximum: 1},
+		"timeout": Duration{},
+		"tags": Array{
+			Items:  IntegerEnum{Options: []int{1, 2, 3}},
+			Unique: true,
+		},
+	},
+}
+
+type jsonTestStruct struct {
+	ID      int64 `json:"id"`
+	Ratio   float32
+	Timeout time.Duration `json:"timeout"`
+	Tags    []int         `json:"tags"`
+}
+
+func TestValidateJSON(t *testing.T) {
+	err := ValidateJSON(jsonTestSchema, []byte(`{
+		"id": 9007199254740993,
+		"ratio": 0.25,
+		"timeout": 60,
+		"tags": [1, 2]
+	}`))
+	assert(err == nil, "Expected no error, got: ", err)
+
+	err = ValidateJSON(jsonTestSchema, []byte(`{"id": 1.5, "tags": [1, 1.0]}`))
+	e, ok := err.(*ValidationError)
+	assert(ok, "Expected a ValidationError, got: ", err)
+	assert(len(e.issues) == 2, "Expected 2 issues, got: ", e)
+	assert(e.issues[0].Keyword() == "type", "Expected type issue for id")
+	assert(e.issues[1].Keyword() == "uniqueItems", "Expected uniqueItems issue for tags")
+	p, ok := e.issues[0].Position()
+	assert(ok, "Expected a position for issue")
+	assert(p.Line == 1 && p.Column == 8, "Unexpected position: ", p)
+}
+
+func TestValidateJSONPositions(t *testing.T) {
+	data := []byte("{\n  \"id\": 1,\n  \"tags\": [1,\n\t\t4],\n  \"other\": {}\n}")
+	e, ok := ValidateJSON(jsonTestSchema, data).(*ValidationError)
+	assert(ok, "Expected a ValidationError")
+	assert(len(e.issues) == 2, "Expected 2 issues, got: ", e)
+
+	p, _ := e.issues[0].Position()
+	assert(e.issues[0].Path() == ".other", "Unexpected path: ", e.issues[0].Path())
+	assert(p.Line == 5 && p.Column == 12, "Unexpected position: ", p)
+	p, _ = e.issues[1].Position()
+	assert(p.Line == 4 && p.Column == 3 && p.Offset == 29, "Unexpected position: ", p)
+
+	// Missing properties have the position of the object
+	s := Object{Properties: jsonTestSchema.Properties, Required: []string{"id"}}
+	e, ok = ValidateJSON(s, []byte(`  {}`)).(*ValidationError)
+	assert(ok, "Expected a ValidationError")
+	p, _ = e.issues[0].Position()
+	assert(p.Line == 1 && p.Column == 3, "Unexpected position: ", p)
+}
+
+func TestValidationErrorRender(t *testing.T) {
+	data := []byte("{\n\t\"tags\": [1, 2, 4]\n}")
+	e := ValidateJSON(jsonTestSchema, data).(*ValidationError)
+	expected := "Value '4' at root.tags[2] is not valid for the enum with options: [1 2 3]\n" +
+		" --> 2:17\n" +
+		"  |\n" +
+		"2 | \t\"tags\": [1, 2, 4]\n" +
+		"  | \t               ^\n"
+	assert(e.Render(data) == expected, "Unexpected rendering:\n", e.Render(data))
+
+	// Long lines are cut around the position
+	data = []byte(`{"tags": [1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 5]}`)
+	s := Object{Properties: Properties{"tags": Array{Items: IntegerEnum{Options: []int{1}}}}}
+	e = ValidateJSON(s, data).(*ValidationError)
+	lines := strings.Split(e.Render(data), "\n")
+	assert(strings.HasPrefix(lines[3], "1 | ...") && strings.HasSuffix(lines[3], "5]}"),
+		"Expected line to be cut: ", lines[3])
+	assert(strings.Index(lines[4], "^") == strings.Index(lines[3], "5]}"),
+		"Expected caret under the value: ", lines[4])
+}
+
+func TestMapJSON(t *testing.T) {
+	var result jsonTestStruct
+	nilOrPanic(MapJSON(jsonTestSchema, []byte(`{
+		"id": 9007199254740993,
+		"ratio": 0.5,
+		"timeout": 60,
+		"tags": [3]
+	}`), &result), "Expected MapJSON to succeed")
+	assert(result.ID == 9007199254740993, "Expected id without loss of precision: ", result.ID)
+	assert(result.Ratio == 0.5, "Unexpected ratio: ", result.Ratio)
+	assert(result.Timeout == time.Minute, "Unexpected timeout: ", result.Timeout)
+	assert(len(result.Tags) == 1 && result.Tags[0] == 3, "Unexpected tags: ", result.Tags)
+}
+
+func TestDecode(t *testing.T) {
+	r := strings.NewReader(`{"id": 1, "tags": [1, 2]}`)
+	var result jsonTestStruct
+	nilOrPanic(Decode(jsonTestSchema, r, &result), "Expected Decode to succeed")
+	assert(result.ID == 1, "Unexpected id: ", result.ID)
+	assert(len(result.Tags) == 2, "Unexpected tags: ", result.Tags)
+
+	// Empty input isn't a syntax error
+	for _, data := range []string{"", " \n"} {
+		err := Decode(jsonTestSchema, strings.NewReader(data), &result)
+		assert(err == io.EOF, "Expected io.EOF for empty input, got: ", err)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	d := NewDecoder(jsonTestSchema, strings.NewReader(`{"id": 1}
+{"id": 2, "tags": [3]}
+`))
+	var ids []int64
+	for {
+		var result jsonTestStruct
+		err := d.Decode(&result)
+		if err == io.EOF {
+			break
+		}
+		nilOrPanic(err, "Expected Decode to succeed")
+		ids = append(ids, result.ID)
+	}
+	assert(len(ids) == 2 && ids[0] == 1 && ids[1] == 2, "Unexpected ids: ", ids)
+
+	// Values are validated, and a value cut short is a syntax error
+	var result jsonTestStruct
+	d = NewDecoder(jsonTestSchema, strings.NewReader(`{"id": "x"} {"id": `))
+	_, ok := d.Decode(&result).(*ValidationError)
+	assert(ok, "Expected a ValidationError for an invalid value")
+	err := d.Decode(&result)
+	e, ok := err.(*ValidationError)
+	assert(ok && err != io.EOF, "Expected a ValidationError, got: ", err)
+	p, _ := e.issues[0].Position()
+	assert(p.Offset == 19, "Unexpected offset: ", p.Offset)
+}
+
+func TestJSONSyntaxError(t *testing.T) {
+	cases := []struct {
+		data   string
+		offset int64
+	}{
+		{`{"id": 1,}`, 10},
+		{`{"id": 1} x`, 10},
+		{`{"id": `, 7},
+		{``, 0},
+	}
+	for _, c := range cases {
+		err := ValidateJSON(jsonTestSchema, []byte(c.data))
+		e, ok := err.(*ValidationError)
+		assert(ok, "Expected a ValidationError for: ", c.data, err)
+		p, ok := e.issues[0].Position()
+		assert(ok, "Expected a position for: ", c.data)
+		assert(p.Offset == c.offset, "Unexpected offset for: ", c.data, p.Offset)
+	}
+
+	var target interface{}
+	err := Decode(jsonTestSchema, strings.NewReader(`[1, 2`), &target)
+	_, ok := err.(*ValidationError)
+	assert(ok, "Expected a ValidationError, got: ", err)
+}
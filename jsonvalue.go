@@ -0,0 +1,241 @@
+package schematypes
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+var typeOfJSONNumber = reflect.TypeOf(json.Number(""))
+
+// integerValue returns data as int64, if data is an integer number. This
+// includes floats and json.Number values with an integral value.
+func integerValue(data interface{}) (int64, bool) {
+	if n, ok := data.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, true
+		}
+		f, err := n.Float64()
+		if err != nil || float64(int64(f)) != f {
+			return 0, false
+		}
+		return int64(f), true
+	}
+	v := reflect.ValueOf(data)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if float64(int64(v.Float())) != v.Float() {
+			return 0, false
+		}
+		return int64(v.Float()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// floatValue returns data as float64, if data is a float64 or json.Number.
+func floatValue(data interface{}) (float64, bool) {
+	switch value := data.(type) {
+	case float64:
+		return value, true
+	case json.Number:
+		f, err := value.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// jsonHash returns a hash of a JSON value, such that values equal by
+// jsonEqual have the same hash. Numbers are hashed by their value, so int(1)
+// and float64(1) have the same hash, and object keys are hashed without
+// regard to ordering.
+func jsonHash(value interface{}) uint64 {
+	return hashValue(reflect.ValueOf(value))
+}
+
+func hashValue(v reflect.Value) uint64 {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return hashUint64(offset64, 'n')
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return hashUint64(offset64, 't')
+		}
+		return hashUint64(offset64, 'f')
+	case reflect.String:
+		if v.Type() == typeOfJSONNumber {
+			f, _ := json.Number(v.String()).Float64()
+			return hashFloat64(f)
+		}
+		h := hashUint64(offset64, 's')
+		s := v.String()
+		for i := 0; i < len(s); i++ {
+			h = (h ^ uint64(s[i])) * prime64
+		}
+		return h
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		// Hash numbers as float64, numbers that can't be represented exactly may
+		// collide, but equal numbers always have the same hash.
+		var f float64
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			f = v.Float()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f = float64(v.Uint())
+		default:
+			f = float64(v.Int())
+		}
+		return hashFloat64(f)
+	case reflect.Slice, reflect.Array:
+		h := hashUint64(offset64, '[')
+		for i := 0; i < v.Len(); i++ {
+			h = hashUint64(h, hashValue(v.Index(i)))
+		}
+		return h
+	case reflect.Map:
+		// Sum the hashes of entries, as ordering of keys doesn't matter
+		var sum uint64
+		for _, key := range v.MapKeys() {
+			sum += hashUint64(hashValue(key), hashValue(v.MapIndex(key)))
+		}
+		return hashUint64(hashUint64(offset64, '{'), sum)
+	default:
+		return 0
+	}
+}
+
+// FNV-1a constants for 64 bit hashes
+const (
+	offset64 = 14695981039346656037
+	prime64  = 1099511628211
+)
+
+// hashFloat64 returns the hash of the number f.
+func hashFloat64(f float64) uint64 {
+	bits := math.Float64bits(f)
+	if f == 0 {
+		bits = 0 // -0 and 0 are equal
+	}
+	return hashUint64(hashUint64(offset64, '0'), bits)
+}
+
+// hashUint64 returns the FNV-1a hash h with the bytes of x added.
+func hashUint64(h, x uint64) uint64 {
+	for i := uint(0); i < 64; i += 8 {
+		h = (h ^ (x >> i & 0xff)) * prime64
+	}
+	return h
+}
+
+// numberValue returns v as a big.Float, v must be a number.
+func numberValue(v reflect.Value) *big.Float {
+	if v.Type() == typeOfJSONNumber {
+		f, _, err := big.ParseFloat(v.String(), 10, 256, big.ToNearestEven)
+		if err != nil {
+			return nil
+		}
+		return f
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Float).SetInt64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Float).SetUint64(v.Uint())
+	default:
+		f := v.Float()
+		if math.IsNaN(f) {
+			return nil
+		}
+		return new(big.Float).SetFloat64(f)
+	}
+}
+
+// isNumber returns true, if v represents a JSON number.
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return v.Type() == typeOfJSONNumber
+}
+
+// jsonEqual returns true, if a and b are equal JSON values. Unlike
+// reflect.DeepEqual numbers are compared by value regardless of type.
+func jsonEqual(a, b interface{}) bool {
+	return equalValues(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+func equalValues(a, b reflect.Value) bool {
+	for a.Kind() == reflect.Interface || a.Kind() == reflect.Ptr {
+		if a.IsNil() {
+			break
+		}
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Interface || b.Kind() == reflect.Ptr {
+		if b.IsNil() {
+			break
+		}
+		b = b.Elem()
+	}
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+
+	if isNumber(a) && isNumber(b) {
+		if a.Kind() == reflect.Float64 && b.Kind() == reflect.Float64 {
+			return a.Float() == b.Float()
+		}
+		x, y := numberValue(a), numberValue(b)
+		return x != nil && y != nil && x.Cmp(y) == 0
+	}
+	if isNumber(a) || isNumber(b) {
+		return false // json.Number isn't equal to a string
+	}
+
+	switch a.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return (b.Kind() == reflect.Interface || b.Kind() == reflect.Ptr) && b.IsNil()
+	case reflect.Bool:
+		return b.Kind() == reflect.Bool && a.Bool() == b.Bool()
+	case reflect.String:
+		return b.Kind() == reflect.String && a.String() == b.String()
+	case reflect.Slice, reflect.Array:
+		if b.Kind() != reflect.Slice && b.Kind() != reflect.Array || a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalValues(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if b.Kind() != reflect.Map || a.Len() != b.Len() ||
+			a.Type().Key().Kind() != reflect.String || b.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		for _, key := range a.MapKeys() {
+			bv := b.MapIndex(reflect.ValueOf(key.String()).Convert(b.Type().Key()))
+			if !bv.IsValid() || !equalValues(a.MapIndex(key), bv) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+}
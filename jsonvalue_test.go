@@ -0,0 +1,33 @@
+package schematypes
+
+import "testing"
+
+func TestJSONEqual(t *testing.T) {
+	equal := [][2]interface{}{
+		{nil, nil},
+		{1, float64(1)},
+		{uint8(3), int64(3)},
+		{"a", "a"},
+		{[]interface{}{1, "a"}, []interface{}{float64(1), "a"}},
+		{[]string{"a"}, []interface{}{"a"}},
+		{map[string]interface{}{"a": 1, "b": true}, map[string]interface{}{"b": true, "a": 1.0}},
+		{map[string]string{"a": "b"}, map[string]interface{}{"a": "b"}},
+	}
+	for _, c := range equal {
+		assert(jsonEqual(c[0], c[1]), "Expected equal: ", c)
+		assert(jsonHash(c[0]) == jsonHash(c[1]), "Expected equal hashes: ", c)
+	}
+
+	different := [][2]interface{}{
+		{nil, false},
+		{1, float64(1.5)},
+		{int64(1 << 62), uint64(1<<62 + 1)},
+		{"1", 1},
+		{[]interface{}{1, 2}, []interface{}{2, 1}},
+		{map[string]interface{}{"a": 1}, map[string]interface{}{"b": 1}},
+		{map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1, "b": 1}},
+	}
+	for _, c := range different {
+		assert(!jsonEqual(c[0], c[1]), "Expected different: ", c)
+	}
+}
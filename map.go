@@ -31,30 +31,39 @@ func (m Map) Schema() map[string]interface{} {
 // Validate the given data, this will return nil if data satisfies this schema.
 // Otherwise, Validate(data) returns a ValidationError instance.
 func (m Map) Validate(data interface{}) error {
+	return m.validate(&validation{}, data)
+}
+
+func (m Map) validate(v *validation, data interface{}) error {
 	value, ok := data.(map[string]interface{})
 	if !ok {
-		return singleIssue("", "Expected object type at {path}")
+		return singleIssue("type", "Expected object type at {path}")
 	}
 
 	e := &ValidationError{}
 
-	for key, value := range value {
-		e.addIssuesWithPrefix(m.Values.Validate(value), formatKeyPath(key))
+	for _, key := range sortedKeys(value) {
+		if v.full(e) {
+			break
+		}
+		l := valueLocation(key)
+		e.addIssuesAt(v.validateNested(m.Values, value[key], l), l)
 	}
 	if m.MinimumProperties > int64(len(value)) {
-		e.addIssue("",
+		e.addIssue("minProperties",
 			"Expected a minimum of %d properties at {path}, but only found %d properties",
 			m.MinimumProperties, len(value),
 		)
 	}
 	if m.MaximumProperties != 0 && m.MaximumProperties < int64(len(value)) {
-		e.addIssue("",
+		e.addIssue("maxProperties",
 			"Expected a maximum of %d properties at {path}, but found %d properties",
 			m.MaximumProperties, len(value),
 		)
 	}
 
 	if len(e.issues) > 0 {
+		e.Sort()
 		return e
 	}
 	return nil
@@ -65,17 +74,25 @@ func (m Map) Map(data, target interface{}) error {
 	if err := m.Validate(data); err != nil {
 		return err
 	}
+	return m.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (m Map) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(m, data, target); ok {
+		return err
+	}
 
 	// Ensure that we have a pointer as input
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(m, target)
 	}
 	val := ptr.Elem()
 
 	// Ensure the type is a map from string to something
 	if val.Kind() != reflect.Map || val.Type().Key().Kind() != reflect.String {
-		return ErrTypeMismatch
+		return typeMismatch(m, target)
 	}
 
 	// Create a new map
@@ -98,14 +115,26 @@ func (m Map) Map(data, target interface{}) error {
 			resultValue = targetValue.Elem()
 		}
 
-		if err := m.Values.Map(value, targetValue.Interface()); err != nil {
-			if err != ErrTypeMismatch {
-				panic("Internal error, this should have been caught in Validate()")
-			}
-			return err
+		if err := mapValidated(m.Values, value, targetValue.Interface()); err != nil {
+			return prefixMapError(err, valueLocation(key))
 		}
 		val.SetMapIndex(reflect.ValueOf(key), resultValue)
 	}
 
 	return nil
 }
+
+func (m Map) unmap(value reflect.Value) (interface{}, error) {
+	if value.Kind() != reflect.Map || value.Type().Key().Kind() != reflect.String {
+		return nil, unmapMismatch(m, value)
+	}
+	result := make(map[string]interface{}, value.Len())
+	for _, key := range value.MapKeys() {
+		item, err := unmapValue(m.Values, value.MapIndex(key))
+		if err != nil {
+			return nil, prefixMapError(err, valueLocation(key.String()))
+		}
+		result[key.String()] = item
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package schematypes
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+// Mapper is implemented by types that can map themselves from data. Map
+// validates data against the schema and then calls MapSchema, instead of
+// mapping data into the target itself.
+//
+// MapSchema may return a ValidationError or a TypeMismatchError, other errors
+// are reported as an issue with the value.
+//
+// Map also hands data to json.Unmarshaler and encoding.TextUnmarshaler
+// targets, except for time.Time, which DateTime parses itself.
+type Mapper interface {
+	MapSchema(schema Schema, data interface{}) error
+}
+
+// mapHook maps data into target, if target implements Mapper,
+// json.Unmarshaler or encoding.TextUnmarshaler, the latter only if data is a
+// string. It returns false, if none of these are used, or if target points to
+// a type handled natively, such as time.Time.
+//
+// Map must validate data against schema before calling mapHook.
+func mapHook(schema Schema, data, target interface{}) (bool, error) {
+	if t := reflect.TypeOf(target); t != nil && t.Kind() == reflect.Ptr && isNative(t.Elem()) {
+		return false, nil
+	}
+	var err error
+	switch t := target.(type) {
+	case Mapper:
+		err = t.MapSchema(schema, data)
+	case json.Unmarshaler:
+		var raw []byte
+		if raw, err = json.Marshal(data); err == nil {
+			err = t.UnmarshalJSON(raw)
+		}
+	case encoding.TextUnmarshaler:
+		value, ok := data.(string)
+		if !ok {
+			return false, nil
+		}
+		err = t.UnmarshalText([]byte(value))
+	default:
+		return false, nil
+	}
+
+	if _, ok := err.(*ValidationError); ok || err == nil || errors.Is(err, ErrTypeMismatch) {
+		return true, err
+	}
+	return true, singleIssue("format", "Value at {path} could not be decoded: %s", err)
+}
@@ -0,0 +1,112 @@
+package schematypes
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mapperTestID struct {
+	Kind  string
+	Value string
+}
+
+func (id *mapperTestID) MapSchema(schema Schema, data interface{}) error {
+	parts := strings.SplitN(data.(string), ":", 2)
+	if len(parts) != 2 {
+		return errors.New("expected kind:value")
+	}
+	id.Kind, id.Value = parts[0], parts[1]
+	return nil
+}
+
+type mapperTestLevel int
+
+func (l *mapperTestLevel) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	*l = mapperTestLevel(len(name))
+	return nil
+}
+
+var mapperTestSchema = Object{
+	Properties: Properties{
+		"id":    String{},
+		"addr":  String{},
+		"level": StringEnum{Options: []string{"low", "high"}},
+		"hosts": Array{Items: String{}},
+	},
+}
+
+type mapperTestStruct struct {
+	ID    mapperTestID    `json:"id"`
+	Addr  net.IP          `json:"addr"`
+	Level mapperTestLevel `json:"level"`
+	Hosts []net.IP        `json:"hosts"`
+}
+
+func TestMapHooks(t *testing.T) {
+	var data interface{}
+	nilOrPanic(json.Unmarshal([]byte(`{
+		"id": "user:42",
+		"addr": "127.0.0.1",
+		"level": "high",
+		"hosts": ["::1"]
+	}`), &data), "Internal test error")
+
+	var result mapperTestStruct
+	nilOrPanic(mapperTestSchema.Map(data, &result), "Expected Map to succeed")
+	assert(result.ID == mapperTestID{"user", "42"}, "Unexpected id: ", result.ID)
+	assert(result.Addr.Equal(net.IPv4(127, 0, 0, 1)), "Unexpected addr: ", result.Addr)
+	assert(result.Level == 4, "Unexpected level: ", result.Level)
+	assert(len(result.Hosts) == 1 && result.Hosts[0].Equal(net.IPv6loopback),
+		"Unexpected hosts: ", result.Hosts)
+}
+
+func TestMapHookNativeTypes(t *testing.T) {
+	// time.Time implements json.Unmarshaler, but DateTime parses it itself
+	var target time.Time
+	ok, _ := mapHook(DateTime{}, "2020-01-02T03:04:05Z", &target)
+	assert(!ok, "Expected no hook for time.Time")
+	nilOrPanic(DateTime{}.Map("2020-01-02T03:04:05Z", &target), "Expected Map to succeed")
+	assert(target.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)), "Unexpected time: ", target)
+
+	var ptr *time.Time
+	nilOrPanic(DateTime{}.Map("2020-01-02T03:04:05.5Z", &ptr), "Expected Map to succeed")
+	assert(ptr != nil && ptr.Nanosecond() == 5e8, "Unexpected time: ", ptr)
+
+	err := String{}.Map("2020-01-02T03:04:05Z", &target)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch for String, got: ", err)
+}
+
+func TestMapHookValidatesFirst(t *testing.T) {
+	var result mapperTestStruct
+	err := mapperTestSchema.Map(map[string]interface{}{"level": "medium"}, &result)
+	e, ok := err.(*ValidationError)
+	assert(ok, "Expected a ValidationError, got: ", err)
+	assert(e.issues[0].Keyword() == "enum", "Expected enum issue, got: ", e)
+}
+
+func TestMapHookError(t *testing.T) {
+	var result mapperTestStruct
+	err := mapperTestSchema.Map(map[string]interface{}{
+		"id":    "user:42",
+		"hosts": []interface{}{"::1", "not-an-ip"},
+	}, &result)
+	e, ok := err.(*ValidationError)
+	assert(ok, "Expected a ValidationError, got: ", err)
+	assert(len(e.issues) == 1, "Expected a single issue")
+	assert(e.issues[0].InstanceLocation() == "/hosts/1",
+		"Unexpected instance location: ", e.issues[0].InstanceLocation())
+	assert(e.issues[0].KeywordLocation() == "/properties/hosts/items/format",
+		"Unexpected keyword location: ", e.issues[0].KeywordLocation())
+
+	err = mapperTestSchema.Map(map[string]interface{}{"id": "invalid"}, &result)
+	_, ok = err.(*ValidationError)
+	assert(ok, "Expected a ValidationError from Mapper, got: ", err)
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
-	"strings"
 )
 
 // Properties defines the properties for a object schema.
@@ -18,6 +17,8 @@ type Object struct {
 	Properties           Properties
 	AdditionalProperties bool
 	Required             []string
+	// Deprecated properties, validation gives a warning when these are used.
+	Deprecated []string
 }
 
 // Schema returns a JSON representation of the schema.
@@ -28,6 +29,14 @@ func (o Object) Schema() map[string]interface{} {
 		props := make(map[string]map[string]interface{})
 		for prop, schema := range o.Properties {
 			props[prop] = schema.Schema()
+			if stringContains(o.Deprecated, prop) {
+				// Copy the schema, as Schema() may return a shared map
+				s := map[string]interface{}{"deprecated": true}
+				for k, v := range props[prop] {
+					s[k] = v
+				}
+				props[prop] = s
+			}
 		}
 		m["properties"] = props
 	}
@@ -50,59 +59,90 @@ func formatKeyPath(key string) string {
 	return "[" + string(j) + "]"
 }
 
+// KeyPath returns the path to the property key of an object, as it appears in
+// ValidationIssue.Path() and TypeMismatchError.Path, such as ".name" or
+// `["a b"]`. This is used by the code written by Generate.
+func KeyPath(key string) string {
+	return formatKeyPath(key)
+}
+
 // Validate the given data, this will return nil if data satisfies this schema.
 // Otherwise, Validate(data) returns a ValidationError instance.
 func (o Object) Validate(data interface{}) error {
+	return o.validate(&validation{}, data)
+}
+
+func (o Object) validate(v *validation, data interface{}) error {
 	value, ok := data.(map[string]interface{})
 	if !ok {
-		return singleIssue("", "Expected object type at {path}")
+		return singleIssue("type", "Expected object type at {path}")
 	}
 
 	e := ValidationError{}
 
-	// Test properties
-	for p, s := range o.Properties {
-		v, ok := value[p]
-		if !ok {
-			continue
-		}
-		if err := s.Validate(v); err != nil {
-			e.addIssuesWithPrefix(err, formatKeyPath(p))
+	// Test properties and test for additional properties, in order of keys
+	// so that issues are reported in a deterministic order
+	for _, key := range sortedKeys(value) {
+		if v.full(&e) {
+			break
 		}
-	}
-
-	// Test for additional properties
-	if !o.AdditionalProperties {
-		for key := range value {
-			if _, ok := o.Properties[key]; !ok {
-				e.addIssue(formatKeyPath(key), "Additional property '%s' not allowed at {path}", key)
+		s, ok := o.Properties[key]
+		if ok {
+			if stringContains(o.Deprecated, key) {
+				v.warnAt(keyElement(key), "deprecated", "Property '%s' at {path} is deprecated", key)
 			}
+			l := propertyLocation(key)
+			e.addIssuesAt(v.validateNested(s, value[key], l), l)
+		} else if !o.AdditionalProperties && !v.options.SkipAdditionalProperties {
+			e.addIssueAt(keyElement(key), "additionalProperties", "Additional property '%s' not allowed at {path}", key)
 		}
 	}
 
 	// Test required properties
-	for _, key := range o.Required {
-		if _, ok := value[key]; !ok {
-			e.addIssue(formatKeyPath(key), "Required property '%s' is missing at {path}", key)
+	if !v.options.SkipRequired {
+		for _, key := range o.Required {
+			if v.full(&e) {
+				break
+			}
+			if _, ok := value[key]; !ok {
+				e.addMissing(key)
+			}
 		}
 	}
 
 	if len(e.issues) > 0 {
+		e.Sort()
 		return &e
 	}
 	return nil
 }
 
 // Map takes data, validates and maps it into the target reference.
+//
+// When mapping into a struct, a field tagged `schema:",remain"` of type
+// map[string]interface{} or json.RawMessage receives the properties not
+// declared in Properties, so they can be forwarded untouched. Unmap adds them
+// back, if AdditionalProperties is true. The field is set to nil when there
+// are no such properties. The field must be declared directly in the struct,
+// a remain field in an embedded struct is a type mismatch, and it isn't mapped
+// as a property itself.
 func (o Object) Map(data, target interface{}) error {
 	if err := o.Validate(data); err != nil {
 		return err
 	}
+	return o.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (o Object) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(o, data, target); ok {
+		return err
+	}
 
 	// Ensure that we have a pointer as input
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(o, target)
 	}
 	val := ptr.Elem()
 
@@ -139,96 +179,203 @@ func (o Object) Map(data, target interface{}) error {
 			if schema == nil {
 				continue // can't map if there is no schema
 			}
-			if err := schema.Map(value, targetValue.Interface()); err != nil {
-				if err != ErrTypeMismatch {
-					panic("Internal error, this should have been caught in Validate()")
-				}
-				return err
+			if err := mapValidated(schema, value, targetValue.Interface()); err != nil {
+				return prefixMapError(err, propertyLocation(key))
 			}
 			val.SetMapIndex(reflect.ValueOf(key), resultValue)
 		}
 		return nil
 	}
 
-	return ErrTypeMismatch
+	return typeMismatch(o, target)
 }
 
-func jsonTag(field reflect.StructField) string {
-	j := field.Tag.Get("json")
-	if strings.HasSuffix(j, ",omitempty") {
-		return j[:len(j)-10]
+func (o Object) unmap(value reflect.Value) (interface{}, error) {
+	result := make(map[string]interface{})
+	switch {
+	case value.Kind() == reflect.Struct:
+		fields := structFields(value.Type())
+		for _, key := range sortedKeys(o.Properties) {
+			f, ok := lookupField(fields, key)
+			if !ok {
+				continue
+			}
+			fv, ok := fieldValue(value, f.index)
+			if !ok || f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			if (fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface) && fv.IsNil() {
+				continue // nil pointers are absent properties
+			}
+			if f.quoted && fv.Kind() != reflect.String {
+				// Values with the string option are unmapped to their JSON string
+				raw, _ := json.Marshal(fv.Interface())
+				fv = reflect.ValueOf(string(raw))
+			}
+			item, err := unmapValue(o.Properties[key], fv)
+			if err != nil {
+				return nil, prefixMapError(err, propertyLocation(key))
+			}
+			result[key] = item
+		}
+		if err := o.unmapRemain(value, result); err != nil {
+			return nil, err
+		}
+	case value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String:
+		for _, key := range value.MapKeys() {
+			var item interface{}
+			var err error
+			if s := o.Properties[key.String()]; s != nil {
+				item, err = unmapValue(s, value.MapIndex(key))
+			} else if o.AdditionalProperties {
+				item, err = unmapJSON(o, value.MapIndex(key))
+			} else {
+				continue
+			}
+			if err != nil {
+				return nil, prefixMapError(err, propertyLocation(key.String()))
+			}
+			result[key.String()] = item
+		}
+	default:
+		return nil, unmapMismatch(o, value)
 	}
-	return j
+	return result, nil
 }
 
-func hasStructTag(t reflect.Type, tag string) bool {
-	N := t.NumField()
-	for i := 0; i < N; i++ {
-		f := t.Field(i)
-		j := jsonTag(f)
-		if j == tag {
-			return true
-		} else if j == "" && f.Name == tag {
-			return true
+// unmapRemain adds the additional properties from the remain field of the
+// struct value to result, if o allows additional properties. Properties
+// declared by o are never taken from the remain field.
+func (o Object) unmapRemain(value reflect.Value, result map[string]interface{}) error {
+	f, ok := remainField(value.Type())
+	if !ok || !o.AdditionalProperties {
+		return nil
+	}
+	if reason := remainReason(f); reason != "" {
+		e := unmapMismatch(o, value)
+		e.Reason = reason
+		return e
+	}
+	data, err := unmapJSON(o, value.FieldByIndex(f.index))
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return nil // nil map or empty json.RawMessage
+	}
+	remain, ok := data.(map[string]interface{})
+	if !ok {
+		e := unmapMismatch(o, value)
+		e.Reason = "the remain field " + f.name + " doesn't hold a JSON object"
+		return e
+	}
+	for key, item := range remain {
+		if _, declared := o.Properties[key]; !declared {
+			result[key] = item
 		}
 	}
-	return false
+	return nil
 }
 
 var typeOfEmptyInterface = reflect.TypeOf((*interface{})(nil)).Elem()
 
+// mapStruct maps data into the struct target, fields are resolved using the
+// same rules as encoding/json, including promotion of fields from embedded
+// structs and case-insensitive matching of property names.
+//
+// This uses a plan cached for o and the struct type, and data must have been
+// validated against o.
 func (o Object) mapStruct(data map[string]interface{}, target reflect.Value) error {
-	t := target.Type()
+	plan := planFor(target.Type())
+	if plan.reason != "" {
+		e := typeMismatch(o, target.Addr().Interface())
+		e.Reason = plan.reason
+		return e
+	}
 
 	// We have a type mismatch if there isn't fields for the values declared
-	for key := range o.Properties {
-		if !hasStructTag(t, key) {
-			return ErrTypeMismatch
-		}
+	op := plan.object(o)
+	if op.found {
+		e := typeMismatch(o, target.Addr().Interface())
+		e.Reason = "there is no field for the property"
+		return prefixMapError(e, propertyLocation(op.missing))
 	}
 
-	for _, key := range o.Required {
-		if !hasStructTag(t, key) {
-			return ErrTypeMismatch
+	for _, pp := range op.properties {
+		key, f := pp.key, pp.field
+		s, declared := o.Properties[key]
+		if !declared {
+			// The properties have changed since the plan was made
+			plan.forget(o)
+			return o.mapStruct(data, target)
+		}
+		value, ok := data[key]
+		if !ok || s == nil {
+			continue // can't map if there is no schema
 		}
-	}
-
-	N := t.NumField()
-	for i := 0; i < N; i++ {
-		// Find field and json tag
-		f := t.Field(i)
-		tag := jsonTag(f)
 
-		// Find value, if there is one
-		value, ok := data[tag]
+		// Find the field, allocating embedded structs as needed
+		field, ok := fieldByIndex(target, f.index)
 		if !ok {
-			continue // We've already validated, so no need to check required
+			return prefixMapError(typeMismatch(o, target.Addr().Interface()), propertyLocation(key))
 		}
 
-		// Find schema for property, ignore if there is none
-		s := o.Properties[tag]
-		if s == nil {
+		var targetValue reflect.Value
+		if f.alloc {
+			targetValue = reflect.New(f.typ.Elem())
+			field.Set(targetValue)
+		} else if f.typ == typeOfEmptyInterface {
+			field.Set(reflect.ValueOf(value))
 			continue
+		} else {
+			targetValue = field.Addr()
 		}
 
-		var targetValue reflect.Value
-		if f.Type.Kind() == reflect.Ptr {
-			targetValue = reflect.New(f.Type.Elem())
-			target.Field(i).Set(targetValue)
-		} else if f.Type == typeOfEmptyInterface {
-			target.Field(i).Set(reflect.ValueOf(value))
+		// Values with the string option are parsed from their JSON string
+		if str, ok := value.(string); ok && f.quoted && targetValue.Elem().Kind() != reflect.String {
+			if json.Unmarshal([]byte(str), targetValue.Interface()) != nil {
+				return prefixMapError(typeMismatch(s, targetValue.Interface()), propertyLocation(key))
+			}
 			continue
-		} else {
-			targetValue = target.Field(i).Addr()
 		}
 
-		// Map value to field
-		err := s.Map(value, targetValue.Interface())
+		// Map nested objects using their plan, as the value is validated
+		var err error
+		if o, ok := s.(Object); ok && f.plain {
+			err = o.mapStruct(value.(map[string]interface{}), targetValue.Elem())
+		} else {
+			err = mapValidated(s, value, targetValue.Interface())
+		}
 		if err != nil {
-			return err
+			return prefixMapError(err, propertyLocation(key))
 		}
 	}
 
+	if plan.remain != nil {
+		// Additional properties goes into the remain field
+		var remain map[string]interface{}
+		for key, value := range data {
+			if _, declared := o.Properties[key]; !declared {
+				if remain == nil {
+					remain = make(map[string]interface{})
+				}
+				remain[key] = value
+			}
+		}
+		field := target.FieldByIndex(plan.remain.index)
+		switch {
+		case remain == nil:
+			field.Set(reflect.Zero(field.Type()))
+		case plan.remain.typ == typeOfRawMessage:
+			raw, err := json.Marshal(remain)
+			if err != nil {
+				return typeMismatch(o, target.Addr().Interface())
+			}
+			field.SetBytes(raw)
+		default:
+			field.Set(reflect.ValueOf(remain))
+		}
+	}
 	return nil
 }
 
@@ -247,6 +394,19 @@ func (o Object) Filter(data map[string]interface{}) map[string]interface{} {
 	return value
 }
 
+// FilterWithWarnings is like Filter, but also returns a warning for each
+// property that was removed.
+func (o Object) FilterWithWarnings(data map[string]interface{}) (map[string]interface{}, []ValidationIssue) {
+	v := &validation{}
+	for _, key := range sortedKeys(data) {
+		if _, ok := o.Properties[key]; !ok && !o.AdditionalProperties {
+			v.warnAt(keyElement(key), "additionalProperties",
+				"Unknown property '%s' at {path} was removed", key)
+		}
+	}
+	return o.Filter(data), (&ValidationError{issues: v.warnings}).Issues("")
+}
+
 // Merge multiple object schemas, this will create an object schema with all the
 // properties from the schemas given, and all the required properties as the
 // given object schemas have.
@@ -261,6 +421,7 @@ func (o Object) Filter(data map[string]interface{}) map[string]interface{} {
 func Merge(a ...Object) (Object, error) {
 	props := make(map[string]Schema)
 	required := []string{}
+	deprecated := []string{}
 
 	for _, obj := range a {
 		// Return an error if AdditionalProperties is set
@@ -288,11 +449,19 @@ func Merge(a ...Object) (Object, error) {
 				required = append(required, k)
 			}
 		}
+
+		// Merge the lists of deprecated properties
+		for _, k := range obj.Deprecated {
+			if !stringContains(deprecated, k) {
+				deprecated = append(deprecated, k)
+			}
+		}
 	}
 
 	return Object{
 		Properties:           props,
 		Required:             required,
 		AdditionalProperties: false,
+		Deprecated:           deprecated,
 	}, nil
 }
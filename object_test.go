@@ -1,6 +1,11 @@
 package schematypes
 
-import "testing"
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
 
 func TestObject(t *testing.T) {
 	var iface interface{}
@@ -210,3 +215,161 @@ func TestNestedObject(t *testing.T) {
 		},
 	}.Test(t)
 }
+
+type objectTestBase struct {
+	ID      int    `json:"id"`
+	Comment string `json:"comment"`
+}
+
+type objectTestLabels struct {
+	Comment string
+}
+
+type objectTestNotes struct {
+	Comment string
+}
+
+func TestObjectStructFields(t *testing.T) {
+	s := Object{
+		Properties: Properties{
+			"id":      Integer{Minimum: 0, Maximum: 100},
+			"name":    String{},
+			"count":   String{Pattern: "^[0-9]+$"},
+			"comment": String{},
+		},
+	}
+	data := map[string]interface{}{
+		"id":      float64(7),
+		"name":    "test",
+		"count":   "42",
+		"comment": "text",
+	}
+
+	var target struct {
+		objectTestBase
+		Name    string
+		Count   int    `json:"count,string"`
+		Ignored string `json:"-"`
+	}
+	nilOrPanic(s.Map(data, &target), "Expected Map to succeed")
+	assert(target.ID == 7, "Expected promoted field to be set")
+	assert(target.Comment == "text", "Expected promoted field to be set")
+	assert(target.Name == "test", "Expected case-insensitive match for name")
+	assert(target.Count == 42, "Expected count to be parsed from string")
+
+	result, err := Unmap(s, target)
+	nilOrPanic(err, "Expected Unmap to succeed")
+	assertJSON(result, `{"id": 7, "name": "test", "count": "42", "comment": "text"}`,
+		"Unexpected result from Unmap")
+
+	// Nil pointers to unexported embedded structs can't be allocated
+	var ptrTarget struct {
+		*objectTestBase
+		Name  string `json:"name"`
+		Count int    `json:"count,string"`
+	}
+	err = s.Map(data, &ptrTarget)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch for nil embedded pointer")
+	ptrTarget.objectTestBase = &objectTestBase{}
+	nilOrPanic(s.Map(data, &ptrTarget), "Expected Map to succeed")
+	assert(ptrTarget.ID == 7, "Expected field in embedded pointer to be set")
+
+	// Fields that conflict at the same depth are ignored, like encoding/json
+	var conflict struct {
+		objectTestLabels
+		objectTestNotes
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Count int    `json:"count,string"`
+	}
+	err = s.Map(data, &conflict)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch for 'comment'")
+
+	// Fields ignored with json:"-" doesn't match properties
+	var ignored struct {
+		objectTestBase
+		Name  string `json:"-"`
+		Count int    `json:"count,string"`
+	}
+	err = s.Map(data, &ignored)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch for 'name'")
+}
+
+func TestObjectRemainField(t *testing.T) {
+	s := Object{
+		Properties: Properties{
+			"name": String{},
+			"size": Integer{Minimum: 0, Maximum: 100},
+		},
+		AdditionalProperties: true,
+	}
+	data := map[string]interface{}{
+		"name":  "test",
+		"extra": "value",
+		"other": map[string]interface{}{"a": float64(1)},
+	}
+
+	var target struct {
+		Name  string                 `json:"name"`
+		Size  int                    `json:"size"`
+		Extra map[string]interface{} `json:"extra" schema:",remain"`
+	}
+	nilOrPanic(s.Map(data, &target), "Expected Map to succeed")
+	assert(target.Name == "test", "Expected name to be set")
+	assertJSON(target.Extra, `{"extra": "value", "other": {"a": 1}}`, "Unexpected remain field")
+
+	result, err := Unmap(s, target)
+	nilOrPanic(err, "Expected Unmap to succeed")
+	assertJSON(result, `{"name": "test", "size": 0, "extra": "value", "other": {"a": 1}}`,
+		"Unexpected result from Unmap")
+
+	// Declared properties are never taken from the remain field
+	target.Extra["name"] = "other"
+	result, err = Unmap(s, target)
+	nilOrPanic(err, "Expected Unmap to succeed")
+	assert(result.(map[string]interface{})["name"] == "test", "Expected declared property to win")
+
+	var raw struct {
+		Name string          `json:"name"`
+		Size int             `json:"size"`
+		Rest json.RawMessage `schema:",remain"`
+	}
+	nilOrPanic(s.Map(data, &raw), "Expected Map to succeed")
+	assertJSON(raw.Rest, `{"extra": "value", "other": {"a": 1}}`, "Unexpected remain field")
+	result, err = Unmap(s, raw)
+	nilOrPanic(err, "Expected Unmap to succeed")
+	assertJSON(result, `{"name": "test", "size": 0, "extra": "value", "other": {"a": 1}}`,
+		"Unexpected result from Unmap")
+
+	// The remain field is reset without additional properties
+	nilOrPanic(s.Map(map[string]interface{}{"name": "x"}, &raw), "Expected Map to succeed")
+	assert(raw.Rest == nil, "Expected remain field to be reset, got: ", string(raw.Rest))
+	target.Extra = map[string]interface{}{"stale": true}
+	nilOrPanic(s.Map(map[string]interface{}{"name": "x"}, &target), "Expected Map to succeed")
+	assert(target.Extra == nil, "Expected remain field to be reset, got: ", target.Extra)
+
+	// Remain fields must have a supported type
+	var invalid struct {
+		Name string            `json:"name"`
+		Size int               `json:"size"`
+		Rest map[string]string `schema:",remain"`
+	}
+	err = s.Map(data, &invalid)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch for remain field")
+	assert(CheckType(s, reflect.TypeOf(invalid)) != nil, "Expected CheckType to fail")
+
+	// Remain fields must be declared directly in the struct
+	type embedded struct {
+		Extra map[string]interface{} `schema:",remain"`
+	}
+	var nested struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+		embedded
+	}
+	err = s.Map(data, &nested)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch for embedded remain field")
+	assert(CheckType(s, reflect.TypeOf(nested)) != nil, "Expected CheckType to fail")
+	_, err = Unmap(s, nested)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected Unmap to fail for embedded remain field")
+}
@@ -0,0 +1,190 @@
+package schematypes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// OutputFormat specifies one of the standard output formats from JSON schema
+// draft 2019-09, used for rendering a ValidationError.
+type OutputFormat int
+
+// Output formats as defined in JSON schema draft 2019-09, section 10.
+const (
+	// OutputFlag renders only whether validation was successful.
+	OutputFlag OutputFormat = iota
+	// OutputBasic renders a flat list of output units.
+	OutputBasic
+	// OutputDetailed renders a hierarchy of output units following the schema,
+	// with units that have a single child replaced by their child.
+	OutputDetailed
+	// OutputVerbose renders the full hierarchy of output units following the
+	// schema.
+	OutputVerbose
+)
+
+// An OutputUnit is a node in the JSON schema 2019-09 output formats.
+type OutputUnit struct {
+	Valid            bool
+	KeywordLocation  string
+	InstanceLocation string
+	Error            string
+	Errors           []OutputUnit
+	// summary is true for the root unit in flag and basic formats, which
+	// carries no locations.
+	summary bool
+}
+
+// MarshalJSON renders the output unit as specified by JSON schema 2019-09.
+func (u OutputUnit) MarshalJSON() ([]byte, error) {
+	type unit struct {
+		Valid            bool         `json:"valid"`
+		KeywordLocation  *string      `json:"keywordLocation,omitempty"`
+		InstanceLocation *string      `json:"instanceLocation,omitempty"`
+		Error            string       `json:"error,omitempty"`
+		Errors           []OutputUnit `json:"errors,omitempty"`
+	}
+	v := unit{
+		Valid:  u.Valid,
+		Error:  u.Error,
+		Errors: u.Errors,
+	}
+	if !u.summary {
+		v.KeywordLocation = &u.KeywordLocation
+		v.InstanceLocation = &u.InstanceLocation
+	}
+	return json.Marshal(v)
+}
+
+// outputUnit returns an output unit for the issue.
+func (v *ValidationIssue) outputUnit() OutputUnit {
+	issue := *v
+	issue.root = "root"
+	return OutputUnit{
+		Valid:            false,
+		KeywordLocation:  v.KeywordLocation(),
+		InstanceLocation: v.InstanceLocation(),
+		Error:            issue.String(),
+	}
+}
+
+// appendOutputUnits appends an output unit for each issue followed by units
+// for its causes.
+func appendOutputUnits(units []OutputUnit, issues []ValidationIssue) []OutputUnit {
+	for _, issue := range issues {
+		units = append(units, issue.outputUnit())
+		units = appendOutputUnits(units, issue.causes)
+	}
+	return units
+}
+
+// An outputNode is used for building the hierarchical output formats.
+type outputNode struct {
+	unit     OutputUnit
+	children []*outputNode
+}
+
+func (n *outputNode) child(keywordLocation, instanceLocation string) *outputNode {
+	for _, c := range n.children {
+		if c.unit.KeywordLocation == keywordLocation &&
+			c.unit.InstanceLocation == instanceLocation && c.unit.Error == "" {
+			return c
+		}
+	}
+	c := &outputNode{unit: OutputUnit{
+		KeywordLocation:  keywordLocation,
+		InstanceLocation: instanceLocation,
+	}}
+	n.children = append(n.children, c)
+	return c
+}
+
+// insert adds issue to the tree, creating a node for each location the issue
+// was found through, skipping the first depth locations.
+func (n *outputNode) insert(issue ValidationIssue, depth int) {
+	node := n
+	for i := depth + 1; i < len(issue.locations); i++ {
+		parent := ValidationIssue{locations: issue.locations[:i]}
+		node = node.child(parent.KeywordLocation(), parent.InstanceLocation())
+	}
+	leaf := &outputNode{unit: issue.outputUnit()}
+	for _, cause := range issue.causes {
+		leaf.insert(cause, len(issue.locations)-1)
+	}
+	node.children = append(node.children, leaf)
+}
+
+// build returns the output unit for the tree, if collapse is true nodes
+// without an error and with a single child are replaced by their child.
+func (n *outputNode) build(collapse bool) OutputUnit {
+	if collapse && len(n.children) == 1 && n.unit.Error == "" {
+		return n.children[0].build(collapse)
+	}
+	u := n.unit
+	for _, c := range n.children {
+		u.Errors = append(u.Errors, c.build(collapse))
+	}
+	return u
+}
+
+// Output returns the ValidationError rendered in the given output format from
+// JSON schema draft 2019-09.
+func (e *ValidationError) Output(format OutputFormat) OutputUnit {
+	switch format {
+	case OutputFlag:
+		return OutputUnit{Valid: false, summary: true}
+	case OutputBasic:
+		u := OutputUnit{Valid: false, summary: true}
+		u.Errors = appendOutputUnits(u.Errors, e.issues)
+		return u
+	default:
+		root := &outputNode{}
+		for _, issue := range e.issues {
+			root.insert(issue, 0)
+		}
+		// Never collapse the root node, so the result always has locations
+		u := root.unit
+		for _, c := range root.children {
+			u.Errors = append(u.Errors, c.build(format == OutputDetailed))
+		}
+		return u
+	}
+}
+
+// MarshalJSON renders the ValidationError in the basic output format from
+// JSON schema draft 2019-09, use Output() for other formats.
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Output(OutputBasic))
+}
+
+// ProblemContentType is the media type for ProblemDetails documents.
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is an RFC 7807 problem details object, suitable as response
+// body for a HTTP 400 response. Errors holds the validation issues in the
+// basic output format from JSON schema draft 2019-09.
+type ProblemDetails struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []OutputUnit `json:"errors,omitempty"`
+}
+
+// ProblemDetails returns the ValidationError as RFC 7807 problem details for
+// a HTTP 400 response, Content-Type should be ProblemContentType.
+func (e *ValidationError) ProblemDetails() ProblemDetails {
+	msgs := make([]string, len(e.issues))
+	for i, issue := range e.issues {
+		issue.root = "root"
+		msgs[i] = issue.String()
+	}
+	return ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+		Detail: strings.Join(msgs, ", "),
+		Errors: e.Output(OutputBasic).Errors,
+	}
+}
@@ -0,0 +1,138 @@
+package schematypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var outputTestSchema = Object{
+	Properties: Properties{
+		"name": String{MaximumLength: 3},
+		"list": Array{
+			Items: Integer{Minimum: 0, Maximum: 10},
+		},
+	},
+	Required: []string{"name", "other"},
+}
+
+func outputTestError() *ValidationError {
+	var data interface{}
+	err := json.Unmarshal([]byte(`{"name": "long", "list": [1, 20, 30]}`), &data)
+	nilOrPanic(err, "Internal test error")
+	e := outputTestSchema.Validate(data)
+	assert(e != nil, "Expected a validation error")
+	return e.(*ValidationError)
+}
+
+func TestValidationIssueLocations(t *testing.T) {
+	e := outputTestError()
+	issue := e.issues[0]
+	assert(issue.Path() == ".list[1]", "Unexpected path: ", issue.Path())
+	assert(issue.InstanceLocation() == "/list/1", "Unexpected instance location")
+	assert(issue.KeywordLocation() == "/properties/list/items/maximum",
+		"Unexpected keyword location: ", issue.KeywordLocation())
+	assert(issue.Keyword() == "maximum", "Unexpected keyword")
+
+	issue = e.issues[3]
+	assert(issue.Path() == ".other", "Unexpected path: ", issue.Path())
+	assert(issue.InstanceLocation() == "", "Expected the object missing the property")
+	assert(issue.KeywordLocation() == "/required", "Unexpected keyword location")
+}
+
+func TestOutputFlag(t *testing.T) {
+	assertJSON(outputTestError().Output(OutputFlag), `{"valid": false}`,
+		"Unexpected flag output")
+}
+
+func TestOutputBasic(t *testing.T) {
+	assertJSON(outputTestError(), `{
+		"valid": false,
+		"errors": [
+			{
+				"valid": false,
+				"keywordLocation": "/properties/list/items/maximum",
+				"instanceLocation": "/list/1",
+				"error": "Integer 20 at root.list[1] is larger than maximum 10"
+			}, {
+				"valid": false,
+				"keywordLocation": "/properties/list/items/maximum",
+				"instanceLocation": "/list/2",
+				"error": "Integer 30 at root.list[2] is larger than maximum 10"
+			}, {
+				"valid": false,
+				"keywordLocation": "/properties/name/maxLength",
+				"instanceLocation": "/name",
+				"error": "String 'long' at root.name is longer than maximum 3 length allowed"
+			}, {
+				"valid": false,
+				"keywordLocation": "/required",
+				"instanceLocation": "",
+				"error": "Required property 'other' is missing at root.other"
+			}
+		]
+	}`, "Unexpected basic output")
+}
+
+func TestOutputDetailed(t *testing.T) {
+	assertJSON(outputTestError().Output(OutputDetailed), `{
+		"valid": false,
+		"keywordLocation": "",
+		"instanceLocation": "",
+		"errors": [
+			{
+				"valid": false,
+				"keywordLocation": "/properties/list",
+				"instanceLocation": "/list",
+				"errors": [
+					{
+						"valid": false,
+						"keywordLocation": "/properties/list/items/maximum",
+						"instanceLocation": "/list/1",
+						"error": "Integer 20 at root.list[1] is larger than maximum 10"
+					}, {
+						"valid": false,
+						"keywordLocation": "/properties/list/items/maximum",
+						"instanceLocation": "/list/2",
+						"error": "Integer 30 at root.list[2] is larger than maximum 10"
+					}
+				]
+			}, {
+				"valid": false,
+				"keywordLocation": "/properties/name/maxLength",
+				"instanceLocation": "/name",
+				"error": "String 'long' at root.name is longer than maximum 3 length allowed"
+			}, {
+				"valid": false,
+				"keywordLocation": "/required",
+				"instanceLocation": "",
+				"error": "Required property 'other' is missing at root.other"
+			}
+		]
+	}`, "Unexpected detailed output")
+}
+
+func TestOutputVerbose(t *testing.T) {
+	u := outputTestError().Output(OutputVerbose)
+	assert(len(u.Errors) == 3, "Expected 3 units at the root")
+	name := u.Errors[1]
+	assert(name.KeywordLocation == "/properties/name", "Expected unit for the property")
+	assert(len(name.Errors) == 1, "Expected a single error for name")
+	assert(name.Errors[0].KeywordLocation == "/properties/name/maxLength",
+		"Expected maxLength error")
+	list := u.Errors[0]
+	assert(len(list.Errors) == 2, "Expected a unit for each item")
+	assert(list.Errors[0].KeywordLocation == "/properties/list/items", "Expected items unit")
+	assert(list.Errors[0].Errors[0].Error != "", "Expected an error message")
+}
+
+func TestProblemDetails(t *testing.T) {
+	p := outputTestError().ProblemDetails()
+	assert(p.Status == 400, "Expected status 400")
+	assert(p.Title == "Bad Request", "Unexpected title: ", p.Title)
+	assert(len(p.Errors) == 4, "Expected 4 errors")
+	data, err := json.Marshal(p)
+	nilOrPanic(err, "Failed to marshal problem details")
+	var v map[string]interface{}
+	nilOrPanic(json.Unmarshal(data, &v), "Failed to parse problem details")
+	assert(v["type"] == "about:blank", "Unexpected type: ", v["type"])
+}
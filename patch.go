@@ -0,0 +1,332 @@
+package schematypes
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ApplyMergePatch applies the JSON Merge Patch (RFC 7396) patch to original
+// and validates the result against schema. The original and patch are JSON
+// values, such as from json.Unmarshal, and are not modified.
+//
+// This returns the result if it satisfies schema, otherwise it returns a
+// ValidationError with paths in the result.
+func ApplyMergePatch(schema Schema, original, patch interface{}) (interface{}, error) {
+	result := mergePatch(copyJSON(original), patch)
+	if err := schema.Validate(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergePatch applies patch to target, which may be modified.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return copyJSON(patch)
+	}
+	result, ok := target.(map[string]interface{})
+	if !ok {
+		result = make(map[string]interface{}, len(p))
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = mergePatch(result[key], value)
+		}
+	}
+	return result
+}
+
+// jsonPointer is the schema for JSON pointers, RFC 6901.
+var jsonPointer = String{
+	Description: "JSON pointer",
+	Pattern:     `^(/([^~]|~[01])*)*$`,
+}
+
+// jsonPatchOperation returns the schema for a JSON Patch operation with the
+// given members, other members are ignored.
+func jsonPatchOperation(required ...string) Variant {
+	properties := Properties{"path": jsonPointer}
+	for _, key := range required {
+		if key == "from" {
+			properties[key] = jsonPointer
+		}
+	}
+	return Variant{Schema: Object{
+		Properties:           properties,
+		AdditionalProperties: true,
+		Required:             required,
+	}}
+}
+
+// jsonPatchSchema is the schema for JSON Patch documents, RFC 6902.
+var jsonPatchSchema = Array{
+	Title: "JSON Patch",
+	Items: TaggedUnion{
+		Discriminator: "op",
+		Variants: map[string]Variant{
+			"add":     jsonPatchOperation("path", "value"),
+			"remove":  jsonPatchOperation("path"),
+			"replace": jsonPatchOperation("path", "value"),
+			"move":    jsonPatchOperation("from", "path"),
+			"copy":    jsonPatchOperation("from", "path"),
+			"test":    jsonPatchOperation("path", "value"),
+		},
+	},
+}
+
+// ApplyJSONPatch applies the JSON Patch (RFC 6902) patch to original and
+// validates the result against schema. The original and patch are JSON values,
+// such as from json.Unmarshal, and are not modified.
+//
+// If the patch isn't a valid JSON Patch document, or an operation can't be
+// applied, such as when a path doesn't exist or a test fails, this returns a
+// ValidationError with paths in the patch document. Otherwise, this returns the
+// result if it satisfies schema, or a ValidationError with paths in the result.
+func ApplyJSONPatch(schema Schema, original, patch interface{}) (interface{}, error) {
+	if err := jsonPatchSchema.Validate(patch); err != nil {
+		return nil, err
+	}
+
+	result := copyJSON(original)
+	ops := reflect.ValueOf(patch)
+	for i := 0; i < ops.Len(); i++ {
+		var err *patchError
+		result, err = applyOperation(result, ops.Index(i).Interface().(map[string]interface{}))
+		if err != nil {
+			return nil, &ValidationError{issues: []ValidationIssue{{
+				message:   err.message,
+				locations: []location{{data: []pathElement{indexElement(i), keyElement(err.key)}}},
+			}}}
+		}
+	}
+
+	if err := schema.Validate(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// A patchError is an operation that can't be applied because of the member key
+// of the operation.
+type patchError struct {
+	key     string
+	message string
+}
+
+func newPatchError(key, message string, args ...interface{}) *patchError {
+	return &patchError{key: key, message: fmt.Sprintf(message, args...)}
+}
+
+// applyOperation applies the JSON Patch operation op to doc, which may be
+// modified.
+func applyOperation(doc interface{}, op map[string]interface{}) (interface{}, *patchError) {
+	path := op["path"].(string)
+	tokens := pointerTokens(path)
+	notFound := newPatchError("path", "Path '%s' at {path} does not exist", path)
+
+	switch op["op"] {
+	case "add":
+		return addValue(doc, tokens, copyJSON(op["value"]), notFound)
+	case "remove":
+		if len(tokens) == 0 {
+			return nil, newPatchError("path", "The root at {path} can't be removed")
+		}
+		return updateParent(doc, tokens, notFound, removeValue)
+	case "replace":
+		if len(tokens) == 0 {
+			return copyJSON(op["value"]), nil
+		}
+		value := copyJSON(op["value"])
+		return updateParent(doc, tokens, notFound, func(parent interface{}, key string) (interface{}, bool) {
+			return replaceValue(parent, key, value)
+		})
+	case "move", "copy":
+		from := op["from"].(string)
+		value, ok := getValue(doc, pointerTokens(from))
+		if !ok {
+			return nil, newPatchError("from", "Path '%s' at {path} does not exist", from)
+		}
+		if op["op"] == "copy" {
+			return addValue(doc, tokens, copyJSON(value), notFound)
+		}
+		if strings.HasPrefix(path, from+"/") {
+			return nil, newPatchError("from", "Path '%s' at {path} can't be moved into itself", from)
+		}
+		if len(pointerTokens(from)) == 0 {
+			return value, nil
+		}
+		doc, _ = updateParent(doc, pointerTokens(from), nil, removeValue)
+		return addValue(doc, tokens, value, notFound)
+	case "test":
+		value, ok := getValue(doc, tokens)
+		if !ok {
+			return nil, notFound
+		}
+		if !jsonEqual(value, op["value"]) {
+			return nil, newPatchError("value", "Value at {path} is not equal to the value at '%s'", path)
+		}
+		return doc, nil
+	}
+	panic("internal error -- validate should have caught this")
+}
+
+// pointerTokens returns the reference tokens of the JSON pointer p.
+func pointerTokens(p string) []string {
+	if p == "" {
+		return nil
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, token := range tokens {
+		token = strings.Replace(token, "~1", "/", -1)
+		tokens[i] = strings.Replace(token, "~0", "~", -1)
+	}
+	return tokens
+}
+
+// arrayIndex returns the array index in token, if it is less than n.
+func arrayIndex(token string, n int) (int, bool) {
+	if token == "" || len(token) > 1 && token[0] == '0' || strings.Trim(token, "0123456789") != "" {
+		return 0, false
+	}
+	i, err := strconv.Atoi(token)
+	return i, err == nil && i < n
+}
+
+// getValue returns the value at tokens in doc.
+func getValue(doc interface{}, tokens []string) (interface{}, bool) {
+	for _, token := range tokens {
+		switch value := doc.(type) {
+		case map[string]interface{}:
+			v, ok := value[token]
+			if !ok {
+				return nil, false
+			}
+			doc = v
+		case []interface{}:
+			i, ok := arrayIndex(token, len(value))
+			if !ok {
+				return nil, false
+			}
+			doc = value[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// updateParent calls update with the parent of the value at tokens and the
+// last token, replacing the parent with the result. This returns notFound if
+// the parent doesn't exist or update returns false.
+func updateParent(doc interface{}, tokens []string, notFound *patchError,
+	update func(parent interface{}, key string) (interface{}, bool),
+) (interface{}, *patchError) {
+	if len(tokens) == 1 {
+		result, ok := update(doc, tokens[0])
+		if !ok {
+			return nil, notFound
+		}
+		return result, nil
+	}
+	child, ok := getValue(doc, tokens[:1])
+	if !ok {
+		return nil, notFound
+	}
+	child, err := updateParent(child, tokens[1:], notFound, update)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := replaceValue(doc, tokens[0], child)
+	return result, nil
+}
+
+// addValue adds value at tokens in doc, as the add operation does.
+func addValue(doc interface{}, tokens []string, value interface{}, notFound *patchError) (interface{}, *patchError) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, notFound, func(parent interface{}, key string) (interface{}, bool) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, true
+		case []interface{}:
+			if key == "-" {
+				return append(p, value), true
+			}
+			i, ok := arrayIndex(key, len(p)+1)
+			if !ok {
+				return nil, false
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, true
+		}
+		return nil, false
+	})
+}
+
+// removeValue removes the value for key in parent.
+func removeValue(parent interface{}, key string) (interface{}, bool) {
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := p[key]; !ok {
+			return nil, false
+		}
+		delete(p, key)
+		return p, true
+	case []interface{}:
+		i, ok := arrayIndex(key, len(p))
+		if !ok {
+			return nil, false
+		}
+		return append(p[:i], p[i+1:]...), true
+	}
+	return nil, false
+}
+
+// replaceValue replaces the existing value for key in parent with value.
+func replaceValue(parent interface{}, key string, value interface{}) (interface{}, bool) {
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := p[key]; !ok {
+			return nil, false
+		}
+		p[key] = value
+		return p, true
+	case []interface{}:
+		i, ok := arrayIndex(key, len(p))
+		if !ok {
+			return nil, false
+		}
+		p[i] = value
+		return p, true
+	}
+	return nil, false
+}
+
+// copyJSON returns a deep copy of the JSON value v, objects and arrays are
+// copied, while other values are returned as is.
+func copyJSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			result[key] = copyJSON(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = copyJSON(item)
+		}
+		return result
+	}
+	return v
+}
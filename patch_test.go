@@ -0,0 +1,127 @@
+package schematypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var patchTestSchema = Object{
+	Properties: Properties{
+		"name": String{},
+		"tags": Array{Items: String{}},
+		"limits": Object{
+			Properties: Properties{
+				"cpu":    Integer{Minimum: 1, Maximum: 8},
+				"memory": Integer{Minimum: 1, Maximum: 1024},
+			},
+		},
+	},
+	Required: []string{"name"},
+}
+
+func parseTestJSON(data string) interface{} {
+	var v interface{}
+	nilOrPanic(json.Unmarshal([]byte(data), &v), "Internal test error")
+	return v
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	original := parseTestJSON(`{"name": "pool", "tags": ["a"], "limits": {"cpu": 2, "memory": 64}}`)
+	result, err := ApplyMergePatch(patchTestSchema, original,
+		parseTestJSON(`{"tags": ["b", "c"], "limits": {"cpu": 4, "memory": null}}`))
+	nilOrPanic(err, "Expected ApplyMergePatch to succeed")
+	assertJSON(result, `{"name": "pool", "tags": ["b", "c"], "limits": {"cpu": 4}}`, "Unexpected result")
+	assertJSON(original, `{"name": "pool", "tags": ["a"], "limits": {"cpu": 2, "memory": 64}}`,
+		"Expected original to be unchanged")
+
+	// Examples from RFC 7396
+	cases := []struct{ original, patch, result string }{
+		{`{"a": "b"}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "b"}`, `{"b": "c"}`, `{"a": "b", "b": "c"}`},
+		{`{"a": "b"}`, `{"a": null}`, `{}`},
+		{`{"a": [{"b": "c"}]}`, `{"a": [1]}`, `{"a": [1]}`},
+		{`["a", "b"]`, `["c", "d"]`, `["c", "d"]`},
+		{`{"a": "b"}`, `["c"]`, `["c"]`},
+		{`{"e": null}`, `{"a": 1}`, `{"e": null, "a": 1}`},
+		{`[1, 2]`, `{"a": "b", "c": null}`, `{"a": "b"}`},
+		{`{}`, `{"a": {"bb": {"ccc": null}}}`, `{"a": {"bb": {}}}`},
+	}
+	for _, c := range cases {
+		result, err := ApplyMergePatch(AllOf{}, parseTestJSON(c.original), parseTestJSON(c.patch))
+		nilOrPanic(err, "Expected ApplyMergePatch to succeed")
+		assertJSON(result, c.result, "Unexpected result for: ", c.patch)
+	}
+
+	// Issues have paths in the result
+	_, err = ApplyMergePatch(patchTestSchema, original, parseTestJSON(`{"limits": {"cpu": 16}}`))
+	e, ok := err.(*ValidationError)
+	assert(ok, "Expected a ValidationError, got: ", err)
+	assert(e.issues[0].Path() == ".limits.cpu", "Unexpected path: ", e.issues[0].Path())
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	original := parseTestJSON(`{"name": "pool", "tags": ["a", "b"], "limits": {"cpu": 2}}`)
+	result, err := ApplyJSONPatch(patchTestSchema, original, parseTestJSON(`[
+		{"op": "test", "path": "/limits/cpu", "value": 2},
+		{"op": "add", "path": "/tags/1", "value": "x"},
+		{"op": "add", "path": "/tags/-", "value": "y"},
+		{"op": "remove", "path": "/tags/0"},
+		{"op": "replace", "path": "/name", "value": "other"},
+		{"op": "copy", "from": "/limits/cpu", "path": "/limits/memory"},
+		{"op": "move", "from": "/tags/2", "path": "/tags/0"}
+	]`))
+	nilOrPanic(err, "Expected ApplyJSONPatch to succeed")
+	assertJSON(result, `{
+		"name": "other",
+		"tags": ["y", "x", "b"],
+		"limits": {"cpu": 2, "memory": 2}
+	}`, "Unexpected result")
+	assertJSON(original, `{"name": "pool", "tags": ["a", "b"], "limits": {"cpu": 2}}`,
+		"Expected original to be unchanged")
+
+	// Pointers are unescaped
+	result, err = ApplyJSONPatch(AllOf{}, parseTestJSON(`{"a/b": {"c~d": 1}}`),
+		parseTestJSON(`[{"op": "replace", "path": "/a~1b/c~0d", "value": 2}]`))
+	nilOrPanic(err, "Expected ApplyJSONPatch to succeed")
+	assertJSON(result, `{"a/b": {"c~d": 2}}`, "Unexpected result")
+}
+
+func TestApplyJSONPatchIssues(t *testing.T) {
+	original := parseTestJSON(`{"name": "pool", "tags": ["a"]}`)
+	issue := func(patch string) ValidationIssue {
+		_, err := ApplyJSONPatch(patchTestSchema, original, parseTestJSON(patch))
+		e, ok := err.(*ValidationError)
+		assert(ok, "Expected a ValidationError for: ", patch, err)
+		assert(len(e.issues) == 1, "Expected a single issue, got: ", e)
+		return e.Issues("")[0]
+	}
+
+	// Invalid operations
+	i := issue(`[{"op": "add", "path": "/tags/-", "value": "b"}, {"op": "add", "path": "/a"}]`)
+	assert(i.Path() == "root[1].value", "Unexpected path: ", i.Path())
+	assert(i.Keyword() == "required", "Unexpected keyword: ", i.Keyword())
+	i = issue(`[{"op": "delete", "path": "/name"}]`)
+	assert(i.Path() == "root[0].op", "Unexpected path: ", i.Path())
+	i = issue(`[{"op": "remove", "path": "name"}]`)
+	assert(i.Path() == "root[0].path", "Unexpected path: ", i.Path())
+
+	// Operations that can't be applied
+	i = issue(`[{"op": "remove", "path": "/tags/1"}]`)
+	assert(i.String() == "Path '/tags/1' at root[0].path does not exist", "Unexpected issue: ", i.String())
+	i = issue(`[{"op": "replace", "path": "/limits/cpu", "value": 1}]`)
+	assert(i.Path() == "root[0].path", "Unexpected path: ", i.Path())
+	i = issue(`[{"op": "add", "path": "/tags/01", "value": "b"}]`)
+	assert(i.Path() == "root[0].path", "Unexpected path: ", i.Path())
+	i = issue(`[{"op": "test", "path": "/name", "value": "other"}]`)
+	assert(i.Path() == "root[0].value", "Unexpected path: ", i.Path())
+	i = issue(`[{"op": "move", "from": "/tags", "path": "/tags/a"}]`)
+	assert(i.Path() == "root[0].from", "Unexpected path: ", i.Path())
+	i = issue(`[{"op": "copy", "from": "/other", "path": "/tags"}]`)
+	assert(i.Path() == "root[0].from", "Unexpected path: ", i.Path())
+
+	// Issues for the result have paths in the result
+	i = issue(`[{"op": "add", "path": "/tags/0", "value": 1}]`)
+	assert(i.Path() == "root.tags[0]", "Unexpected path: ", i.Path())
+	i = issue(`[{"op": "remove", "path": "/name"}]`)
+	assert(i.Keyword() == "required", "Unexpected keyword: ", i.Keyword())
+}
@@ -0,0 +1,216 @@
+package schematypes
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// A structPlan is the plan for mapping objects into a struct type, plans are
+// cached by planFor, so the reflection is only done once per type.
+type structPlan struct {
+	fields []structField
+	// remain is the field tagged `schema:",remain"`, if any, and reason
+	// explains why the remain field can't be used, if not empty
+	remain *structField
+	reason string
+	// byName holds the fieldPlan for each property name looked up
+	byName sync.Map
+	// objects holds the *objectPlan for the Objects mapped into the type, by
+	// the address of their Properties, and nobjects counts them
+	objects  sync.Map
+	nobjects int32
+}
+
+// An objectPlan is the plan for mapping an Object into a struct type, with the
+// fields resolved for each property, so this is only done once per Object and
+// type. The plan is for the properties and required properties it was made
+// from, the schemas are looked up when mapping.
+type objectPlan struct {
+	// properties holds the fields for the declared properties, sorted by key
+	properties []propertyPlan
+	required   []string
+	// missing is the first property, in sorted order, that there is no field
+	// for, if found is true
+	missing string
+	found   bool
+}
+
+// A propertyPlan is the plan for mapping a declared property.
+type propertyPlan struct {
+	key   string
+	field fieldPlan
+}
+
+// A fieldPlan is the plan for mapping a property into a struct field.
+type fieldPlan struct {
+	structField
+	// alloc is true, if the field is a pointer that must be allocated
+	alloc bool
+	// plain is true, if the field is a struct or pointer to a struct that
+	// doesn't implement any of the hooks used by Map. If the schema is an
+	// Object, such fields are mapped with the plan for the nested struct,
+	// without validating the value again.
+	plain bool
+}
+
+// planCache holds the *structPlan for each struct type.
+var planCache sync.Map
+
+// maxObjectPlans is the number of objectPlans kept for each struct type, as
+// Objects may be created on the fly, the plans are dropped when there are more.
+const maxObjectPlans = 16
+
+var (
+	typeOfMapper          = reflect.TypeOf((*Mapper)(nil)).Elem()
+	typeOfJSONUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	typeOfTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	typeOfRawMessage      = reflect.TypeOf(json.RawMessage(nil))
+	typeOfRemainMap       = reflect.TypeOf(map[string]interface{}(nil))
+)
+
+// planFor returns the plan for mapping objects into the struct type t.
+func planFor(t reflect.Type) *structPlan {
+	if plan, ok := planCache.Load(t); ok {
+		return plan.(*structPlan)
+	}
+	plan, _ := planCache.LoadOrStore(t, newStructPlan(t))
+	return plan.(*structPlan)
+}
+
+// newStructPlan returns the plan for mapping objects into the struct type t.
+func newStructPlan(t reflect.Type) *structPlan {
+	plan := &structPlan{fields: structFields(t)}
+
+	// Additional properties are kept in the remain field, if there is one
+	if f, ok := remainField(t); ok {
+		plan.reason = remainReason(f)
+		plan.remain = &f
+	}
+	return plan
+}
+
+// field returns the plan for the field that the property key is mapped into,
+// or false if there is no such field.
+func (p *structPlan) field(key string) (fieldPlan, bool) {
+	if fp, ok := p.byName.Load(key); ok {
+		return fp.(fieldPlan), fp.(fieldPlan).index != nil
+	}
+	var fp fieldPlan
+	if f, ok := lookupField(p.fields, key); ok {
+		fp = fieldPlan{structField: f, alloc: f.typ.Kind() == reflect.Ptr}
+		ft := f.typ
+		if fp.alloc {
+			ft = ft.Elem()
+		}
+		fp.plain = ft.Kind() == reflect.Struct && !hasHook(ft) && !isNative(ft)
+	}
+	p.byName.Store(key, fp)
+	return fp, fp.index != nil
+}
+
+// missing returns the first property declared by o, in sorted order, that
+// there is no field for.
+func (p *structPlan) missing(o Object) (string, bool) {
+	missing, found := "", false
+	check := func(key string) {
+		if _, ok := p.field(key); !ok && (!found || key < missing) {
+			missing, found = key, true
+		}
+	}
+	for key := range o.Properties {
+		check(key)
+	}
+	for _, key := range o.Required {
+		check(key)
+	}
+	return missing, found
+}
+
+// object returns the plan for mapping o into the struct type, the plan is
+// cached unless the properties or required properties of o have changed.
+//
+// Properties with the same number of keys are assumed to have the same keys,
+// so callers must check that each property in the plan is still declared, and
+// call forget if it isn't.
+func (p *structPlan) object(o Object) *objectPlan {
+	id := reflect.ValueOf(o.Properties).Pointer()
+	if op, ok := p.objects.Load(id); ok && op.(*objectPlan).matches(o) {
+		return op.(*objectPlan)
+	}
+	op := p.newObjectPlan(o)
+	if atomic.AddInt32(&p.nobjects, 1) > maxObjectPlans {
+		p.objects.Range(func(key, _ interface{}) bool {
+			p.objects.Delete(key)
+			return true
+		})
+		atomic.StoreInt32(&p.nobjects, 1)
+	}
+	p.objects.Store(id, op)
+	return op
+}
+
+// forget drops the cached plan for o, because its properties have changed.
+func (p *structPlan) forget(o Object) {
+	p.objects.Delete(reflect.ValueOf(o.Properties).Pointer())
+}
+
+// newObjectPlan returns the plan for mapping o into the struct type.
+func (p *structPlan) newObjectPlan(o Object) *objectPlan {
+	op := &objectPlan{
+		properties: make([]propertyPlan, 0, len(o.Properties)),
+		required:   append([]string(nil), o.Required...),
+	}
+	op.missing, op.found = p.missing(o)
+	for _, key := range sortedKeys(o.Properties) {
+		f, _ := p.field(key)
+		op.properties = append(op.properties, propertyPlan{key: key, field: f})
+	}
+	return op
+}
+
+// matches returns true, if op has the same number of properties and the same
+// required properties as o.
+func (op *objectPlan) matches(o Object) bool {
+	if len(op.properties) != len(o.Properties) || len(op.required) != len(o.Required) {
+		return false
+	}
+	for i, key := range o.Required {
+		if op.required[i] != key {
+			return false
+		}
+	}
+	return true
+}
+
+// remainReason returns the reason the remain field f is a mismatch, or the
+// empty string if f is supported.
+func remainReason(f structField) string {
+	if len(f.index) > 1 {
+		return "the remain field " + f.name + " must be declared directly in the struct, not in an embedded struct"
+	}
+	if f.typ != typeOfRemainMap && f.typ != typeOfRawMessage {
+		return "the remain field " + f.name + " must be map[string]interface{} or json.RawMessage"
+	}
+	return ""
+}
+
+// hasHook returns true, if a pointer to t implements any of the interfaces
+// that Map hands values to.
+func hasHook(t reflect.Type) bool {
+	if isNative(t) {
+		return false
+	}
+	p := reflect.PtrTo(t)
+	return p.Implements(typeOfMapper) || p.Implements(typeOfJSONUnmarshaler) ||
+		p.Implements(typeOfTextUnmarshaler)
+}
+
+// isNative returns true, if t is handled by the schema types in this package,
+// so Map doesn't hand values to the hooks t implements. This is time.Time,
+// which DateTime parses with the formats it accepts.
+func isNative(t reflect.Type) bool {
+	return t == typeOfTime
+}
@@ -0,0 +1,126 @@
+package schematypes
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var planTestSchema = Object{
+	Properties: Properties{
+		"name": String{},
+		"size": Integer{Minimum: 0, Maximum: 100},
+		"owner": Object{
+			Properties: Properties{"id": String{}},
+			Required:   []string{"id"},
+		},
+	},
+	Required: []string{"name"},
+}
+
+type planTestOwner struct {
+	ID string `json:"id"`
+}
+
+type planTestStruct struct {
+	Name  string         `json:"name"`
+	Size  *int           `json:"size"`
+	Owner *planTestOwner `json:"owner"`
+}
+
+func planTestData() map[string]interface{} {
+	return map[string]interface{}{
+		"name":  "test",
+		"size":  float64(7),
+		"owner": map[string]interface{}{"id": "me"},
+	}
+}
+
+func TestPlanCache(t *testing.T) {
+	typ := reflect.TypeOf(planTestStruct{})
+	plan := planFor(typ)
+	assert(planFor(typ) == plan, "Expected the plan to be cached")
+	f, ok := plan.field("size")
+	assert(ok && f.alloc, "Expected size to be allocated")
+	f, ok = plan.field("owner")
+	assert(ok && f.plain, "Expected owner to use a nested plan")
+	f, ok = plan.field("name")
+	assert(ok && !f.plain, "Expected name not to use a nested plan")
+	_, ok = plan.field("other")
+	assert(!ok, "Expected no field for other")
+	op := plan.object(planTestSchema)
+	assert(plan.object(planTestSchema) == op, "Expected the object plan to be cached")
+	assert(!op.found && len(op.properties) == 3, "Unexpected object plan: ", op)
+
+	// Type mismatches are cached too
+	var mismatch struct {
+		Name string `json:"name"`
+	}
+	for i := 0; i < 2; i++ {
+		err := planTestSchema.Map(planTestData(), &mismatch)
+		assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+	}
+
+	var result planTestStruct
+	for i := 0; i < 2; i++ {
+		result = planTestStruct{}
+		nilOrPanic(planTestSchema.Map(planTestData(), &result), "Expected Map to succeed")
+		assert(result.Name == "test" && *result.Size == 7, "Unexpected result: ", result)
+		assert(result.Owner != nil && result.Owner.ID == "me", "Unexpected owner: ", result.Owner)
+	}
+}
+
+func TestPlanModifiedSchema(t *testing.T) {
+	s := Object{Properties: Properties{"a": String{}}}
+	var target struct {
+		A string `json:"a"`
+		B int    `json:"b"`
+	}
+	nilOrPanic(s.Map(map[string]interface{}{"a": "x"}, &target), "Expected Map to succeed")
+	assert(target.A == "x", "Unexpected target: ", target)
+
+	// Plans doesn't depend on the schema, so it may be modified
+	delete(s.Properties, "a")
+	s.Properties["b"] = Integer{Minimum: 0, Maximum: 10}
+	nilOrPanic(s.Map(map[string]interface{}{"b": float64(3)}, &target), "Expected Map to succeed")
+	assert(target.B == 3, "Unexpected target: ", target)
+
+	s.Properties["c"] = String{}
+	err := s.Map(map[string]interface{}{"b": float64(3)}, &target)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch for c, got: ", err)
+}
+
+func BenchmarkMapStruct(b *testing.B) {
+	s := Array{Items: planTestSchema}
+	data := make([]interface{}, 100)
+	for i := range data {
+		data[i] = planTestData()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var result []planTestStruct
+		nilOrPanic(s.Map(data, &result), "Expected Map to succeed")
+	}
+}
+
+type planTestWide struct {
+	A, B, C, D, E, F, G, H, I, J, K, L, M, N, O, P string
+	Size                                           int `json:"size"`
+}
+
+// BenchmarkMapStructWide maps into a struct with many fields, calling mapStruct
+// directly, so this measures the cost of the plan rather than validation.
+func BenchmarkMapStructWide(b *testing.B) {
+	o := Object{Properties: Properties{"size": Integer{Minimum: 0, Maximum: 100}}, Required: []string{"size"}}
+	for _, key := range "ABCDEFGHIJKLMNOP" {
+		o.Properties[string(key)] = String{}
+	}
+	data := map[string]interface{}{"A": "a", "size": float64(7)}
+	nilOrPanic(o.Validate(data), "Expected data to be valid")
+	var result planTestWide
+	target := reflect.ValueOf(&result).Elem()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nilOrPanic(o.mapStruct(data, target), "Expected mapStruct to succeed")
+	}
+}
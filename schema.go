@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/xeipuuv/gojsonschema"
@@ -57,34 +58,127 @@ func (s schema) Schema() map[string]interface{} {
 }
 
 func (s schema) Validate(data interface{}) error {
-	result, _ := s.schema.Validate(gojsonschema.NewGoLoader(data))
+	result, err := s.schema.Validate(gojsonschema.NewGoLoader(data))
+	if err != nil {
+		return singleIssue("", "Failed to validate sub-schema at {path}, error: %s", err)
+	}
 	if result.Valid() {
 		return nil
 	}
-	msgs := []string{}
-	for _, e := range result.Errors() {
-		msgs = append(msgs, e.Description())
+
+	e := &ValidationError{}
+	for _, r := range result.Errors() {
+		e.issues = append(e.issues, resultIssue(r, data))
+	}
+	return e
+}
+
+// resultKeywords maps gojsonschema error types to the keyword causing them.
+var resultKeywords = map[string]string{
+	"invalid_type":                    "type",
+	"number_any_of":                   "anyOf",
+	"number_one_of":                   "oneOf",
+	"number_all_of":                   "allOf",
+	"number_not":                      "not",
+	"missing_dependency":              "dependencies",
+	"array_no_additional_items":       "additionalItems",
+	"array_min_items":                 "minItems",
+	"array_max_items":                 "maxItems",
+	"unique":                          "uniqueItems",
+	"array_min_properties":            "minProperties",
+	"array_max_properties":            "maxProperties",
+	"additional_property_not_allowed": "additionalProperties",
+	"invalid_property_pattern":        "patternProperties",
+	"invalid_property_name":           "propertyNames",
+	"string_gte":                      "minLength",
+	"string_lte":                      "maxLength",
+	"multiple_of":                     "multipleOf",
+	"number_gte":                      "minimum",
+	"number_gt":                       "exclusiveMinimum",
+	"number_lte":                      "maximum",
+	"number_lt":                       "exclusiveMaximum",
+	"condition_then":                  "then",
+	"condition_else":                  "else",
+}
+
+// resultIssue returns a ValidationIssue for an error from gojsonschema, data
+// is the value validated, needed to tell array indexes from object keys.
+func resultIssue(r gojsonschema.ResultError, data interface{}) ValidationIssue {
+	// Find path elements by walking the context, gojsonschema uses the first
+	// argument as delimiter, we use \x00 as it is unlikely to appear in a key.
+	var path []pathElement
+	tokens := strings.Split(r.Context().String("\x00"), "\x00")
+	value := reflect.ValueOf(data)
+	for _, token := range tokens[1:] { // skip (root)
+		for value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+			value = value.Elem()
+		}
+		i, err := strconv.Atoi(token)
+		if (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) &&
+			err == nil && i >= 0 && i < value.Len() {
+			path = append(path, indexElement(i))
+			value = value.Index(i)
+			continue
+		}
+		path = append(path, keyElement(token))
+		if value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String {
+			value = value.MapIndex(reflect.ValueOf(token).Convert(value.Type().Key()))
+		} else {
+			value = reflect.Value{}
+		}
+	}
+
+	// Issues about a property has the property as detail
+	missing := ""
+	if p, ok := r.Details()["property"].(string); ok {
+		switch r.Type() {
+		case "required":
+			missing = p
+			path = append(path, keyElement(p))
+		case "additional_property_not_allowed", "invalid_property_name":
+			path = append(path, keyElement(p))
+		}
+	}
+
+	keyword, ok := resultKeywords[r.Type()]
+	if !ok {
+		keyword = r.Type()
 	}
 
-	return singleIssue("", "Faild to validate sub-schema at {path}, errors: %s",
-		strings.Join(msgs, ", "),
-	)
+	// gojsonschema doesn't tell where in the schema the keyword is, so only
+	// the keyword is given
+	return ValidationIssue{
+		message: r.Description() + " at {path}",
+		locations: []location{{
+			data:    path,
+			schema:  []string{keyword},
+			partial: true,
+		}},
+		property: missing,
+	}
 }
 
 func (s schema) Map(data, target interface{}) error {
 	if err := s.Validate(data); err != nil {
 		return err
 	}
+	return s.mapValidated(data, target)
+}
+
+func (s schema) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(s, data, target); ok {
+		return err
+	}
 
 	// Ensure that we have a pointer as input
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
 	}
 	val := ptr.Elem()
 
 	if val.Type() != typeOfEmptyInterface {
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
 	}
 
 	val.Set(reflect.ValueOf(data))
@@ -1,6 +1,9 @@
 package schematypes
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+)
 
 func TestSchema(t *testing.T) {
 	var aInterface interface{}
@@ -67,3 +70,46 @@ func TestSchema(t *testing.T) {
 		},
 	}.Test(t)
 }
+
+func TestSchemaIssues(t *testing.T) {
+	s, err := NewSchema(`{
+    "type": "object",
+    "properties": {
+      "list": {
+        "type": "array",
+        "items": {"type": "integer", "maximum": 10}
+      },
+      "dict": {
+        "type": "object",
+        "additionalProperties": {"type": "string"}
+      }
+    },
+    "additionalProperties": false,
+    "required": ["list"]
+  }`)
+	nilOrPanic(err, "Failed to create schema")
+	obj := Object{
+		Properties: Properties{
+			"config": s,
+		},
+	}
+	var data interface{}
+	nilOrPanic(json.Unmarshal([]byte(`{"config": {
+    "list": [1, 42],
+    "dict": {"0": 5, "a.b": "x"},
+    "extra": true
+  }}`), &data), "Bad JSON")
+	e := obj.Validate(data).(*ValidationError)
+
+	issues := map[string]string{}
+	for _, issue := range e.Issues("") {
+		issues[issue.Path()] = issue.Keyword()
+		assert(issue.KeywordLocation() == "", "Expected no keyword location, got: ",
+			issue.KeywordLocation())
+	}
+	assertJSON(issues, `{
+    "root.config.list[1]": "maximum",
+    "root.config.dict[\"0\"]": "type",
+    "root.config.extra": "additionalProperties"
+  }`, "Unexpected issues: ", issues)
+}
@@ -0,0 +1,242 @@
+package schematypes
+
+import (
+	"reflect"
+	"sort"
+)
+
+// A TaggedUnion is an object schema with a discriminator property that
+// selects one of the variants. This is represented as oneOf in the JSON
+// schema, but validation only checks the selected variant, giving precise
+// issues, and Map can allocate the Go type registered for the variant.
+//
+// Example:
+//
+//	TaggedUnion{
+//		Discriminator: "type",
+//		Variants: map[string]Variant{
+//			"docker": {Schema: dockerSchema, Type: reflect.TypeOf(DockerConfig{})},
+//			"shell":  {Schema: shellSchema, Type: reflect.TypeOf(ShellConfig{})},
+//		},
+//	}
+type TaggedUnion struct {
+	Title         string
+	Description   string
+	Discriminator string
+	Variants      map[string]Variant
+}
+
+// A Variant is an option in a TaggedUnion.
+type Variant struct {
+	// Schema for the variant, the discriminator property is added by the
+	// TaggedUnion and shouldn't be declared here.
+	Schema Object
+	// Type is the struct type Map allocates for the variant, when mapping into
+	// an interface. If nil, the variant can't be mapped into an interface
+	// other than interface{}.
+	Type reflect.Type
+}
+
+// tags returns the discriminator values for the variants in sorted order.
+func (u TaggedUnion) tags() []string {
+	tags := make([]string, 0, len(u.Variants))
+	for tag := range u.Variants {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// variantSchema returns the schema for the variant with tag, including the
+// discriminator property.
+func (u TaggedUnion) variantSchema(tag string) Object {
+	s := u.Variants[tag].Schema
+	props := Properties{u.Discriminator: StringEnum{Options: []string{tag}}}
+	for key, schema := range s.Properties {
+		props[key] = schema
+	}
+	s.Properties = props
+	s.Required = append([]string{u.Discriminator}, s.Required...)
+	return s
+}
+
+// Schema returns a JSON representation of the schema.
+func (u TaggedUnion) Schema() map[string]interface{} {
+	m := makeMetaData(u.Title, u.Description)
+	m["type"] = "object"
+	tags := u.tags()
+	m["properties"] = map[string]interface{}{
+		u.Discriminator: StringEnum{Options: tags}.Schema(),
+	}
+	m["required"] = []string{u.Discriminator}
+	variants := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		variants[i] = u.variantSchema(tag).Schema()
+	}
+	m["oneOf"] = variants
+	return m
+}
+
+// Validate the given data, this will return nil if data satisfies this schema.
+// Otherwise, Validate(data) returns a ValidationError instance.
+func (u TaggedUnion) Validate(data interface{}) error {
+	return u.validate(&validation{}, data)
+}
+
+func (u TaggedUnion) validate(v *validation, data interface{}) error {
+	value, ok := data.(map[string]interface{})
+	if !ok {
+		return singleIssue("type", "Expected object type at {path}")
+	}
+
+	tag, ok := value[u.Discriminator]
+	if !ok {
+		e := &ValidationError{}
+		e.addMissing(u.Discriminator)
+		return e
+	}
+	tags := u.tags()
+	i := -1
+	if s, ok := tag.(string); ok {
+		i = sort.SearchStrings(tags, s)
+		if i == len(tags) || tags[i] != s {
+			i = -1
+		}
+	}
+	if i == -1 {
+		e := &ValidationError{}
+		e.addIssuesAt(singleIssue("enum",
+			"Value '%v' at {path} is not valid for the enum with options: %v", tag, tags,
+		), propertyLocation(u.Discriminator))
+		return e
+	}
+
+	e := &ValidationError{}
+	l := subSchemaLocation("oneOf", i)
+	e.addIssuesAt(v.validateAt(u.variantSchema(tags[i]), data, l), l)
+	if len(e.issues) > 0 {
+		return e
+	}
+	return nil
+}
+
+// Map takes data, validates and maps it into the target reference.
+//
+// If target is a pointer to an interface, Map allocates a value of the Type
+// registered for the selected variant, and sets it if it or a pointer to it
+// implements the interface. Otherwise, the target must be a pointer to the
+// Type of the selected variant or a map.
+func (u TaggedUnion) Map(data, target interface{}) error {
+	if err := u.Validate(data); err != nil {
+		return err
+	}
+	return u.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (u TaggedUnion) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(u, data, target); ok {
+		return err
+	}
+
+	// Ensure that we have a pointer as input
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Ptr {
+		return typeMismatch(u, target)
+	}
+	val := ptr.Elem()
+
+	value := data.(map[string]interface{})
+	tag := value[u.Discriminator].(string)
+	variant := u.Variants[tag]
+
+	// Map into the concrete type, if target is an interface
+	if val.Kind() == reflect.Interface {
+		if variant.Type == nil {
+			if val.Type() != typeOfEmptyInterface {
+				return typeMismatch(u, target)
+			}
+			val.Set(reflect.ValueOf(data))
+			return nil
+		}
+		result := reflect.New(variant.Type)
+		if err := u.mapVariant(tag, value, result); err != nil {
+			return err
+		}
+		if variant.Type.AssignableTo(val.Type()) {
+			val.Set(result.Elem())
+		} else if result.Type().AssignableTo(val.Type()) {
+			val.Set(result)
+		} else {
+			return typeMismatch(u, target)
+		}
+		return nil
+	}
+
+	// Map into maps with all properties, including the discriminator
+	if val.Kind() == reflect.Map {
+		return u.variantSchema(tag).mapValidated(data, target)
+	}
+
+	if variant.Type == nil || val.Type() != variant.Type {
+		return typeMismatch(u, target)
+	}
+	return u.mapVariant(tag, value, ptr)
+}
+
+// mapVariant maps data into target using the schema for the variant with tag,
+// the discriminator is set, if the target struct has a string field for it.
+func (u TaggedUnion) mapVariant(tag string, data map[string]interface{}, target reflect.Value) error {
+	// Map without the discriminator, as the variant schema doesn't have it
+	value := make(map[string]interface{}, len(data))
+	for key, v := range data {
+		if key != u.Discriminator {
+			value[key] = v
+		}
+	}
+	if err := u.Variants[tag].Schema.mapValidated(value, target.Interface()); err != nil {
+		return err
+	}
+
+	val := target.Elem()
+	if val.Kind() == reflect.Struct {
+		if f, ok := lookupField(structFields(val.Type()), u.Discriminator); ok && f.typ.Kind() == reflect.String {
+			if field, ok := fieldByIndex(val, f.index); ok {
+				field.SetString(tag)
+			}
+		}
+	}
+	return nil
+}
+
+func (u TaggedUnion) unmap(value reflect.Value) (interface{}, error) {
+	tag := ""
+	switch value.Kind() {
+	case reflect.Struct:
+		for t, variant := range u.Variants {
+			if variant.Type == value.Type() {
+				tag = t
+			}
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() == reflect.String {
+			d := value.MapIndex(reflect.ValueOf(u.Discriminator).Convert(value.Type().Key()))
+			for d.IsValid() && d.Kind() == reflect.Interface {
+				d = d.Elem()
+			}
+			if d.IsValid() && d.Kind() == reflect.String {
+				tag = d.String()
+			}
+		}
+	}
+	if _, ok := u.Variants[tag]; !ok {
+		return nil, unmapMismatch(u, value)
+	}
+
+	data, err := unmapValue(u.Variants[tag].Schema, value)
+	if err != nil {
+		return nil, err
+	}
+	data.(map[string]interface{})[u.Discriminator] = tag
+	return data, nil
+}
@@ -0,0 +1,160 @@
+package schematypes
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type taggedUnionTestEngine interface {
+	Name() string
+}
+
+type taggedUnionTestDocker struct {
+	Type  string `json:"type"`
+	Image string `json:"image"`
+}
+
+func (d taggedUnionTestDocker) Name() string { return "docker" }
+
+type taggedUnionTestShell struct {
+	Command []string `json:"command"`
+}
+
+func (s *taggedUnionTestShell) Name() string { return "shell" }
+
+var taggedUnionTestSchema = TaggedUnion{
+	Title:         "engine",
+	Discriminator: "type",
+	Variants: map[string]Variant{
+		"docker": {
+			Schema: Object{
+				Properties: Properties{"image": String{}},
+				Required:   []string{"image"},
+			},
+			Type: reflect.TypeOf(taggedUnionTestDocker{}),
+		},
+		"shell": {
+			Schema: Object{
+				Properties: Properties{"command": Array{Items: String{}}},
+			},
+			Type: reflect.TypeOf(taggedUnionTestShell{}),
+		},
+	},
+}
+
+func TestTaggedUnion(t *testing.T) {
+	var iface interface{}
+	testCase{
+		Schema: taggedUnionTestSchema,
+		Match: `{
+			"title": "engine",
+			"type": "object",
+			"properties": {"type": {"type": "string", "enum": ["docker", "shell"]}},
+			"required": ["type"],
+			"oneOf": [{
+				"type": "object",
+				"properties": {
+					"type": {"type": "string", "enum": ["docker"]},
+					"image": {"type": "string"}
+				},
+				"additionalProperties": false,
+				"required": ["type", "image"]
+			}, {
+				"type": "object",
+				"properties": {
+					"type": {"type": "string", "enum": ["shell"]},
+					"command": {"type": "array", "items": {"type": "string"}}
+				},
+				"additionalProperties": false,
+				"required": ["type"]
+			}]
+		}`,
+		Valid: []string{
+			`{"type": "docker", "image": "ubuntu"}`,
+			`{"type": "shell", "command": ["ls"]}`,
+			`{"type": "shell"}`,
+		},
+		Invalid: []string{
+			`{"image": "ubuntu"}`,
+			`{"type": "other"}`,
+			`{"type": "docker"}`,
+			`{"type": "shell", "image": "ubuntu"}`,
+			`[]`,
+		},
+		TypeMatch: []interface{}{
+			&iface,
+			new(taggedUnionTestEngine),
+			&map[string]interface{}{},
+		},
+		TypeMismatch: []interface{}{
+			new(error),
+			new(string),
+		},
+	}.Test(t)
+}
+
+func TestTaggedUnionIssues(t *testing.T) {
+	issue := func(data string) ValidationIssue {
+		var v interface{}
+		nilOrPanic(json.Unmarshal([]byte(data), &v), "Internal test error")
+		e, ok := taggedUnionTestSchema.Validate(v).(*ValidationError)
+		assert(ok, "Expected a ValidationError for: ", data)
+		assert(len(e.issues) == 1, "Expected a single issue, got: ", e)
+		return e.issues[0]
+	}
+
+	i := issue(`{"type": "docker", "image": 42}`)
+	assert(i.InstanceLocation() == "/image", "Unexpected instance location: ", i.InstanceLocation())
+	assert(i.KeywordLocation() == "/oneOf/0/properties/image/type",
+		"Unexpected keyword location: ", i.KeywordLocation())
+
+	i = issue(`{"type": "other"}`)
+	assert(i.KeywordLocation() == "/properties/type/enum",
+		"Unexpected keyword location: ", i.KeywordLocation())
+
+	i = issue(`{}`)
+	assert(i.Keyword() == "required", "Unexpected keyword: ", i.Keyword())
+}
+
+func TestTaggedUnionMap(t *testing.T) {
+	var target struct {
+		Engine taggedUnionTestEngine `json:"engine"`
+	}
+	s := Object{Properties: Properties{"engine": taggedUnionTestSchema}}
+
+	data := map[string]interface{}{
+		"engine": map[string]interface{}{"type": "docker", "image": "ubuntu"},
+	}
+	nilOrPanic(s.Map(data, &target), "Expected Map to succeed")
+	d, ok := target.Engine.(taggedUnionTestDocker)
+	assert(ok, "Expected docker engine, got: ", target.Engine)
+	assert(d.Image == "ubuntu" && d.Type == "docker", "Unexpected docker engine: ", d)
+
+	data = map[string]interface{}{
+		"engine": map[string]interface{}{"type": "shell", "command": []interface{}{"ls"}},
+	}
+	nilOrPanic(s.Map(data, &target), "Expected Map to succeed")
+	sh, ok := target.Engine.(*taggedUnionTestShell)
+	assert(ok, "Expected shell engine, got: ", target.Engine)
+	assert(len(sh.Command) == 1, "Unexpected shell engine: ", sh)
+
+	// Map into the concrete type
+	var docker taggedUnionTestDocker
+	err := taggedUnionTestSchema.Map(map[string]interface{}{
+		"type": "docker", "image": "debian",
+	}, &docker)
+	nilOrPanic(err, "Expected Map to succeed")
+	assert(docker.Image == "debian", "Unexpected image: ", docker.Image)
+
+	err = taggedUnionTestSchema.Map(map[string]interface{}{"type": "shell"}, &docker)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+
+	// Unmap finds the variant from the type
+	result, err := Unmap(s, struct {
+		Engine taggedUnionTestEngine `json:"engine"`
+	}{&taggedUnionTestShell{Command: []string{"ls"}}})
+	nilOrPanic(err, "Expected Unmap to succeed")
+	assertJSON(result, `{"engine": {"type": "shell", "command": ["ls"]}}`, "Unexpected result")
+}
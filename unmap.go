@@ -0,0 +1,75 @@
+package schematypes
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Unmap takes a Go value and returns the JSON compatible data it represents
+// according to schema, this is the reverse of Map. The result consists of
+// map[string]interface{}, []interface{}, string, int64, float64, bool and nil
+// values, using the property names and formats from schema.
+//
+// The result is validated against schema, Unmap returns a ValidationError
+// instance if it doesn't satisfy the schema. If value can't be represented by
+// schema Unmap returns a TypeMismatchError.
+func Unmap(schema Schema, value interface{}) (interface{}, error) {
+	data, err := unmapValue(schema, reflect.ValueOf(value))
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.Validate(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// An unmapper is a Schema that can convert Go values into JSON compatible
+// data, schemas that doesn't implement this are unmapped using encoding/json.
+type unmapper interface {
+	unmap(value reflect.Value) (interface{}, error)
+}
+
+// unmapValue returns the JSON compatible data for value according to s,
+// pointers and interfaces are dereferenced and nil is unmapped to nil.
+func unmapValue(s Schema, value reflect.Value) (interface{}, error) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil, nil
+	}
+
+	if s, ok := s.(unmapper); ok {
+		return s.unmap(value)
+	}
+	return unmapJSON(s, value)
+}
+
+// unmapJSON returns the JSON compatible data for value by encoding and
+// decoding it with encoding/json.
+func unmapJSON(s Schema, value reflect.Value) (interface{}, error) {
+	if !value.CanInterface() {
+		return nil, unmapMismatch(s, value)
+	}
+	raw, err := json.Marshal(value.Interface())
+	if err != nil {
+		return nil, unmapMismatch(s, value)
+	}
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, unmapMismatch(s, value)
+	}
+	return data, nil
+}
+
+// unmapMismatch returns a TypeMismatchError for unmapping value with s.
+func unmapMismatch(s Schema, value reflect.Value) *TypeMismatchError {
+	return &TypeMismatchError{
+		SchemaType: reflect.TypeOf(s).Name(),
+		TargetType: value.Type(),
+	}
+}
@@ -0,0 +1,147 @@
+package schematypes
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var unmapTestSchema = Object{
+	Properties: Properties{
+		"name":     String{MaximumLength: 10},
+		"count":    Integer{Minimum: 0, Maximum: 100},
+		"ratio":    Number{Minimum: 0, Maximum: 1},
+		"enabled":  Boolean{},
+		"mode":     StringEnum{Options: []string{"fast", "slow"}},
+		"created":  DateTime{},
+		"timeout":  Duration{},
+		"delay":    Duration{},
+		"homepage": URI{},
+		"tags":     Array{Items: String{}},
+		"labels":   Map{Values: Integer{Minimum: 0, Maximum: 10}},
+		"parent":   Object{Properties: Properties{"id": Integer{Minimum: 0, Maximum: 100}}},
+		"extra":    AnyOf{Integer{Minimum: 0, Maximum: 10}, String{}},
+	},
+	Required: []string{"name", "count"},
+}
+
+type unmapTestParent struct {
+	ID int `json:"id"`
+}
+
+type unmapTestStruct struct {
+	Name     string           `json:"name"`
+	Count    uint8            `json:"count"`
+	Ratio    float32          `json:"ratio"`
+	Enabled  bool             `json:"enabled"`
+	Mode     string           `json:"mode,omitempty"`
+	Created  time.Time        `json:"created"`
+	Timeout  time.Duration    `json:"timeout"`
+	Delay    time.Duration    `json:"delay"`
+	Homepage *url.URL         `json:"homepage"`
+	Tags     []string         `json:"tags"`
+	Labels   map[string]int   `json:"labels"`
+	Parent   *unmapTestParent `json:"parent"`
+	Extra    interface{}      `json:"extra"`
+	internal string
+}
+
+func TestUnmap(t *testing.T) {
+	homepage, _ := url.Parse("https://example.com/home")
+	value := unmapTestStruct{
+		Name:     "test",
+		Count:    42,
+		Ratio:    0.5,
+		Enabled:  true,
+		Created:  time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC),
+		Timeout:  26*time.Hour + 5*time.Minute,
+		Delay:    90 * time.Second,
+		Homepage: homepage,
+		Tags:     []string{"a", "b"},
+		Labels:   map[string]int{"x": 1},
+		Extra:    "text",
+		internal: "hidden",
+	}
+	data, err := Unmap(unmapTestSchema, value)
+	nilOrPanic(err, "Expected Unmap to succeed")
+	assertJSON(data, `{
+		"name": "test",
+		"count": 42,
+		"ratio": 0.5,
+		"enabled": true,
+		"created": "2016-01-02T03:04:05Z",
+		"timeout": "1d 2h 5m",
+		"delay": 90,
+		"homepage": "https://example.com/home",
+		"tags": ["a", "b"],
+		"labels": {"x": 1},
+		"extra": "text"
+	}`, "Unexpected result from Unmap")
+	assert(data.(map[string]interface{})["count"] == int64(42), "Expected count as int64")
+
+	// Map the data back and compare
+	var result unmapTestStruct
+	nilOrPanic(unmapTestSchema.Map(data, &result), "Expected Map to succeed")
+	assert(result.Timeout == value.Timeout, "Unexpected timeout: ", result.Timeout)
+	assert(result.Created.Equal(value.Created), "Unexpected created: ", result.Created)
+	assert(result.Homepage.String() == homepage.String(), "Unexpected homepage")
+}
+
+func TestUnmapPointer(t *testing.T) {
+	data, err := Unmap(unmapTestSchema, &unmapTestStruct{
+		Name:   "test",
+		Mode:   "slow",
+		Parent: &unmapTestParent{ID: 7},
+	})
+	nilOrPanic(err, "Expected Unmap to succeed")
+	obj := data.(map[string]interface{})
+	assert(obj["mode"] == "slow", "Expected mode")
+	assertJSON(obj["parent"], `{"id": 7}`, "Unexpected parent")
+}
+
+func TestUnmapInvalid(t *testing.T) {
+	_, err := Unmap(unmapTestSchema, unmapTestStruct{Name: "too-long-name", Count: 200})
+	e, ok := err.(*ValidationError)
+	assert(ok, "Expected a ValidationError, got: ", err)
+	assert(len(e.issues) == 2, "Expected 2 issues, got: ", e)
+}
+
+func TestUnmapTypeMismatch(t *testing.T) {
+	_, err := Unmap(unmapTestSchema, map[string]interface{}{
+		"name":  "test",
+		"count": 1,
+		"tags":  []interface{}{"a", 2},
+	})
+	assert(errors.Is(err, ErrTypeMismatch), "Expected ErrTypeMismatch, got: ", err)
+	var e *TypeMismatchError
+	assert(errors.As(err, &e), "Expected TypeMismatchError")
+	assert(e.Path == ".tags[1]", "Unexpected path: ", e.Path)
+	assert(e.SchemaType == "String", "Unexpected schema type: ", e.SchemaType)
+
+	_, err = Unmap(Duration{}, 1500*time.Millisecond)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected ErrTypeMismatch for fractional duration")
+}
+
+func TestUnmapComposites(t *testing.T) {
+	s := AllOf{
+		Object{
+			Properties:           Properties{"id": Integer{Minimum: 0, Maximum: 100}},
+			AdditionalProperties: true,
+		},
+		Object{
+			Properties:           Properties{"name": String{}},
+			AdditionalProperties: true,
+		},
+	}
+	data, err := Unmap(s, struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}{ID: 1, Name: "a"})
+	nilOrPanic(err, "Expected Unmap to succeed")
+	assertJSON(data, `{"id": 1, "name": "a"}`, "Unexpected result for AllOf")
+
+	data, err = Unmap(OneOf{Integer{Minimum: 0, Maximum: 10}, DateTime{}}, time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC))
+	nilOrPanic(err, "Expected Unmap to succeed")
+	assert(data == "2016-01-02T00:00:00Z", "Unexpected result for OneOf: ", data)
+}
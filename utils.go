@@ -1,6 +1,10 @@
 package schematypes
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
 
 // stringContains returns true if list contains element
 func stringContains(list []string, element string) bool {
@@ -22,6 +26,16 @@ func intContains(list []int, element int) bool {
 	return false
 }
 
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V interface{}](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // MustValidate panics if data doesn't validate against schema
 func MustValidate(schema Schema, data interface{}) {
 	err := schema.Validate(data)
@@ -37,10 +51,10 @@ func MustValidate(schema Schema, data interface{}) {
 // ErrTypeMismatch
 func MustMap(schema Schema, data, target interface{}) error {
 	err := schema.Map(data, target)
-	if err == ErrTypeMismatch {
+	if errors.Is(err, ErrTypeMismatch) {
 		panic(fmt.Sprintf(
-			"ErrTypeMismatch, target type: %#v doesn't match schema: %#v",
-			target, schema,
+			"%s, target type: %#v doesn't match schema: %#v",
+			err, target, schema,
 		))
 	}
 	return err
@@ -49,10 +63,10 @@ func MustMap(schema Schema, data, target interface{}) error {
 // MustValidateAndMap panics if data doesn't validate or maps into target
 func MustValidateAndMap(schema Schema, data, target interface{}) {
 	err := schema.Map(data, target)
-	if err == ErrTypeMismatch {
+	if errors.Is(err, ErrTypeMismatch) {
 		panic(fmt.Sprintf(
-			"ErrTypeMismatch, target type: %#v doesn't match schema: %#v",
-			target, schema,
+			"%s, target type: %#v doesn't match schema: %#v",
+			err, target, schema,
 		))
 	}
 	if err != nil {
@@ -2,6 +2,7 @@ package schematypes
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
@@ -83,13 +84,13 @@ func testMap(t *testing.T, s Schema, jsonString string, target interface{}, vali
 			fmt.Println("Expected a validation error, got no error")
 			fail = true
 		}
-		if !typeMatch && err == ErrTypeMismatch {
+		if !typeMatch && errors.Is(err, ErrTypeMismatch) {
 			fmt.Println("Expected validation error got ErrTypeMismatch")
 			fail = true
 		}
 	} else {
 		if !typeMatch {
-			if err != ErrTypeMismatch {
+			if !errors.Is(err, ErrTypeMismatch) {
 				fmt.Println("Expected ErrTypeMismatch, but got err = ", err)
 				fail = true
 			}
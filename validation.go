@@ -0,0 +1,335 @@
+package schematypes
+
+import (
+	"context"
+	"reflect"
+	"sort"
+)
+
+// Options for validation with ValidateWithOptions.
+type Options struct {
+	// FailFast stops validation at the first issue found, useful when only
+	// pass/fail matters.
+	FailFast bool
+	// MaxIssues is the maximum number of issues to collect, zero for no limit.
+	MaxIssues int
+	// SkipAdditionalProperties disables the test for additional properties
+	// not allowed by Object schemas.
+	SkipAdditionalProperties bool
+	// SkipRequired disables the test for required properties in Object
+	// schemas, useful when validating partial documents.
+	SkipRequired bool
+	// Coerce converts strings to the types declared by the schema before
+	// validation, using Coerce, for data from environment variables, query
+	// strings and the like. OnCoerce is called for each conversion, if not nil.
+	Coerce   bool
+	OnCoerce func(Coercion)
+
+	// Limits for validation of untrusted data, zero for no limit. When a limit
+	// is exceeded validation of the value stops with an issue for which
+	// LimitExceeded() returns true.
+
+	// MaxDepth is the maximum nesting depth of objects and arrays.
+	MaxDepth int
+	// MaxArrayLength is the maximum number of items in an array.
+	MaxArrayLength int
+	// MaxProperties is the maximum number of keys in an object.
+	MaxProperties int
+	// MaxStringLength is the maximum length of a string in bytes.
+	MaxStringLength int
+}
+
+// A validation holds the state of a validation.
+type validation struct {
+	options   Options
+	ctx       context.Context
+	err       error      // error from ctx, if validation was aborted
+	steps     int        // number of values validated
+	depth     int        // current nesting depth
+	locations []location // locations from the root to the current sub-schema
+	warnings  []ValidationIssue
+}
+
+// A validator is a Schema that can be validated with options, this is
+// implemented by schemas that have sub-schemas or that gives warnings.
+type validator interface {
+	validate(v *validation, data interface{}) error
+}
+
+// A validatedMapper is a Schema that can map data that has already been
+// validated against it, this is implemented by the schemas in this package.
+type validatedMapper interface {
+	mapValidated(data, target interface{}) error
+}
+
+// mapValidated maps data, which must be valid against s, into target without
+// validating it again, if s supports it.
+func mapValidated(s Schema, data, target interface{}) error {
+	if s, ok := s.(validatedMapper); ok {
+		return s.mapValidated(data, target)
+	}
+	return s.Map(data, target)
+}
+
+// validate data against s, passing on the validation state if s supports it.
+func (v *validation) validate(s Schema, data interface{}) error {
+	if !v.step() {
+		return nil
+	}
+
+	if s, ok := s.(validator); ok {
+		if err := v.checkLimits(data); err != nil {
+			return err
+		}
+		return s.validate(v, data)
+	}
+
+	// Other schemas don't check the limits for the values nested in data, nor
+	// for cancellation, so check all of data before validating it.
+	if err := v.checkTree(data); err != nil || v.err != nil {
+		return err
+	}
+	return s.Validate(data)
+}
+
+// step counts a value validated, returning false if validation was aborted.
+func (v *validation) step() bool {
+	// Check for cancellation once in a while, not for every value
+	if v.ctx != nil && v.steps%256 == 0 && v.err == nil {
+		v.err = v.ctx.Err()
+	}
+	v.steps++
+	return v.err == nil
+}
+
+// validateAt validates data against the sub-schema s at location l.
+func (v *validation) validateAt(s Schema, data interface{}, l location) error {
+	v.locations = append(v.locations, l)
+	defer func() { v.locations = v.locations[:len(v.locations)-1] }()
+	return v.validate(s, data)
+}
+
+// validateNested validates data nested inside the value being validated, such
+// as an array item or object property, against the sub-schema s at location l.
+func (v *validation) validateNested(s Schema, data interface{}, l location) error {
+	v.depth++
+	defer func() { v.depth-- }()
+	if v.options.MaxDepth > 0 && v.depth > v.options.MaxDepth {
+		return limitIssue("Value at {path} is nested deeper than the limit of %d",
+			v.options.MaxDepth)
+	}
+	return v.validateAt(s, data, l)
+}
+
+// warn adds a warning caused by keyword for the value being validated.
+func (v *validation) warn(keyword, message string, args ...interface{}) {
+	e := &ValidationError{}
+	e.addIssue(keyword, message, args...)
+	v.addWarnings(e)
+}
+
+// warnAt adds a warning caused by keyword for the element p of the value
+// being validated.
+func (v *validation) warnAt(p pathElement, keyword, message string, args ...interface{}) {
+	e := &ValidationError{}
+	e.addIssueAt(p, keyword, message, args...)
+	v.addWarnings(e)
+}
+
+func (v *validation) addWarnings(e *ValidationError) {
+	for _, issue := range e.issues {
+		for i := len(v.locations) - 1; i >= 0; i-- {
+			issue = issue.prefix(v.locations[i])
+		}
+		v.warnings = append(v.warnings, issue)
+	}
+}
+
+// checkLimits returns a ValidationError if data exceeds the limits in options.
+func (v *validation) checkLimits(data interface{}) error {
+	o := v.options
+	if o.MaxArrayLength == 0 && o.MaxProperties == 0 && o.MaxStringLength == 0 {
+		return nil
+	}
+	value := reflect.ValueOf(data)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		if o.MaxArrayLength > 0 && value.Len() > o.MaxArrayLength {
+			return limitIssue("Array at {path} has %d items, more than the limit of %d",
+				value.Len(), o.MaxArrayLength)
+		}
+	case reflect.Map:
+		if o.MaxProperties > 0 && value.Len() > o.MaxProperties {
+			return limitIssue("Object at {path} has %d properties, more than the limit of %d",
+				value.Len(), o.MaxProperties)
+		}
+	case reflect.String:
+		if value.Type() == typeOfJSONNumber {
+			return nil
+		}
+		if o.MaxStringLength > 0 && value.Len() > o.MaxStringLength {
+			return limitIssue("String at {path} has length %d, more than the limit of %d",
+				value.Len(), o.MaxStringLength)
+		}
+	}
+	return nil
+}
+
+// checkTree returns a ValidationError if data or any of the values nested in
+// it exceeds the limits in options.
+func (v *validation) checkTree(data interface{}) error {
+	if err := v.checkLimits(data); err != nil {
+		return err
+	}
+	o := v.options
+	if o.MaxDepth == 0 && o.MaxArrayLength == 0 && o.MaxProperties == 0 &&
+		o.MaxStringLength == 0 && v.ctx == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(data)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			err := v.checkNested(indexElement(i), value.Index(i).Interface())
+			if err != nil || v.err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		keys := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			item := value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key()))
+			err := v.checkNested(keyElement(key), item.Interface())
+			if err != nil || v.err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkNested checks the limits for data nested at p in the value being
+// checked by checkTree.
+func (v *validation) checkNested(p pathElement, data interface{}) error {
+	v.depth++
+	defer func() { v.depth-- }()
+	var err error
+	if v.options.MaxDepth > 0 && v.depth > v.options.MaxDepth {
+		err = limitIssue("Value at {path} is nested deeper than the limit of %d",
+			v.options.MaxDepth)
+	} else if v.step() {
+		err = v.checkTree(data)
+	}
+	if err == nil {
+		return nil
+	}
+	e := &ValidationError{}
+	e.addIssuesAt(err, location{data: []pathElement{p}})
+	return e
+}
+
+// limitIssue returns a ValidationError with an issue for which
+// LimitExceeded() returns true.
+func limitIssue(message string, args ...interface{}) *ValidationError {
+	e := singleIssue("", message, args...)
+	e.issues[0].limitExceeded = true
+	return e
+}
+
+// limit returns the maximum number of issues to collect, zero for no limit.
+func (v *validation) limit() int {
+	if v.options.FailFast {
+		return 1
+	}
+	return v.options.MaxIssues
+}
+
+// full returns true, if no more issues should be added to e.
+func (v *validation) full(e *ValidationError) bool {
+	limit := v.limit()
+	return v.err != nil || limit > 0 && len(e.issues) >= limit
+}
+
+// ValidateWithOptions validates data against schema with the given options.
+// This returns nil if data satisfies schema, otherwise it returns a
+// ValidationError instance.
+func ValidateWithOptions(schema Schema, data interface{}, options Options) error {
+	return ValidateContext(context.Background(), schema, data, options)
+}
+
+// ValidateContext validates data against schema with the given options, if
+// ctx is done before validation is completed, this returns ctx.Err().
+//
+// Use this with the limits in Options when validating untrusted data.
+func ValidateContext(ctx context.Context, schema Schema, data interface{}, options Options) error {
+	_, err := validateContext(ctx, schema, data, options)
+	return err
+}
+
+// validateContext is ValidateContext, returning data as validated, that is
+// after coercion if options.Coerce is true.
+func validateContext(ctx context.Context, schema Schema, data interface{}, options Options) (interface{}, error) {
+	v := &validation{options: options, ctx: ctx}
+	if options.Coerce {
+		// Check the limits and for cancellation first, as coercion copies data
+		// and splits strings into arrays
+		if !v.step() {
+			return data, v.err
+		}
+		err := v.checkTree(data)
+		if v.err != nil {
+			return data, v.err
+		}
+		if err != nil {
+			return data, err
+		}
+
+		record := options.OnCoerce
+		if record == nil {
+			record = func(Coercion) {}
+		}
+		data = coerce(schema, data, "", record)
+	}
+
+	err := v.validate(schema, data)
+	if v.err != nil {
+		return data, v.err
+	}
+	if e, ok := err.(*ValidationError); ok {
+		if limit := v.limit(); limit > 0 && len(e.issues) > limit {
+			e.issues = e.issues[:limit]
+		}
+	}
+	return data, err
+}
+
+// ValidateWithWarnings validates data against schema, returning warnings for
+// conditions that doesn't cause validation to fail, such as use of deprecated
+// properties. Warnings are returned even if validation fails, in which case
+// err is a ValidationError instance.
+func ValidateWithWarnings(schema Schema, data interface{}) (warnings []ValidationIssue, err error) {
+	v := &validation{}
+	err = v.validate(schema, data)
+	warnings = (&ValidationError{issues: v.warnings}).Issues("")
+	return
+}
+
+// MapContext validates data against schema with the given options and maps
+// it into target, if ctx is done before validation is completed, this returns
+// ctx.Err(). With options.Coerce the data is coerced before it is validated
+// and mapped.
+func MapContext(ctx context.Context, schema Schema, data, target interface{}, options Options) error {
+	data, err := validateContext(ctx, schema, data, options)
+	if err != nil {
+		return err
+	}
+	return mapValidated(schema, data, target)
+}
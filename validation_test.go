@@ -0,0 +1,225 @@
+package schematypes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+var optionsTestSchema = Object{
+	Properties: Properties{
+		"name": String{MaximumLength: 3},
+		"list": Array{
+			Items: Integer{Minimum: 0, Maximum: 10},
+		},
+	},
+	Required: []string{"name", "other"},
+}
+
+func optionsTestData() interface{} {
+	var data interface{}
+	err := json.Unmarshal([]byte(`{
+		"list": [1, 20, 30, 40],
+		"extra": true
+	}`), &data)
+	nilOrPanic(err, "Internal test error")
+	return data
+}
+
+func TestValidateWithOptions(t *testing.T) {
+	err := ValidateWithOptions(optionsTestSchema, optionsTestData(), Options{})
+	assert(len(err.(*ValidationError).issues) == 6, "Expected 6 issues, got: ", err)
+}
+
+func TestValidateWithOptionsFailFast(t *testing.T) {
+	err := ValidateWithOptions(optionsTestSchema, optionsTestData(), Options{
+		FailFast: true,
+	})
+	assert(len(err.(*ValidationError).issues) == 1, "Expected 1 issue, got: ", err)
+
+	err = ValidateWithOptions(optionsTestSchema, map[string]interface{}{
+		"name": "abc",
+		"list": []interface{}{float64(4)},
+	}, Options{FailFast: true, SkipRequired: true})
+	assert(err == nil, "Expected no error, got: ", err)
+}
+
+func TestValidateWithOptionsMaxIssues(t *testing.T) {
+	err := ValidateWithOptions(optionsTestSchema, optionsTestData(), Options{
+		MaxIssues: 4,
+	})
+	assert(len(err.(*ValidationError).issues) == 4, "Expected 4 issues, got: ", err)
+}
+
+func TestValidateWithOptionsSkip(t *testing.T) {
+	err := ValidateWithOptions(optionsTestSchema, optionsTestData(), Options{
+		SkipAdditionalProperties: true,
+		SkipRequired:             true,
+	})
+	e := err.(*ValidationError)
+	assert(len(e.issues) == 3, "Expected 3 issues, got: ", err)
+	for _, issue := range e.issues {
+		assert(issue.Keyword() == "maximum", "Unexpected issue: ", issue.String())
+	}
+
+	err = ValidateWithOptions(Array{Items: optionsTestSchema}, []interface{}{
+		map[string]interface{}{"name": "abc", "extra": 5},
+	}, Options{
+		SkipAdditionalProperties: true,
+		SkipRequired:             true,
+	})
+	assert(err == nil, "Expected options to apply to nested schemas, got: ", err)
+}
+
+func TestValidateContextLimits(t *testing.T) {
+	s := Object{
+		Properties: Properties{
+			"list": Array{Items: Array{Items: String{}}},
+			"dict": Map{Values: Integer{Minimum: 0, Maximum: 10}},
+		},
+	}
+	var data interface{}
+	nilOrPanic(json.Unmarshal([]byte(`{
+		"list": [["a"], ["b", "c", "d"], ["long-string"]],
+		"dict": {"a": 1, "b": 2, "c": 3}
+	}`), &data), "Internal test error")
+
+	limits := []Options{
+		{MaxDepth: 2},
+		{MaxArrayLength: 2},
+		{MaxProperties: 2},
+		{MaxStringLength: 5},
+	}
+	for _, options := range limits {
+		err := ValidateContext(context.Background(), s, data, options)
+		e, ok := err.(*ValidationError)
+		assert(ok, "Expected a ValidationError for options: ", options, err)
+		for _, issue := range e.issues {
+			assert(issue.LimitExceeded(), "Expected limit issue, got: ", issue.String())
+		}
+	}
+
+	err := ValidateContext(context.Background(), s, data, Options{
+		MaxDepth:        3,
+		MaxArrayLength:  3,
+		MaxProperties:   3,
+		MaxStringLength: 11,
+	})
+	assert(err == nil, "Expected no error, got: ", err)
+}
+
+func TestValidateContextLimitsRawSchema(t *testing.T) {
+	raw, err := NewSchema(`{
+		"type": "object",
+		"properties": {
+			"list": {"type": "array", "items": {"type": "array"}}
+		}
+	}`)
+	nilOrPanic(err, "Internal test error")
+	var data interface{}
+	nilOrPanic(json.Unmarshal([]byte(`{
+		"list": [["a"], ["b", "c", "d"], ["long-string"]]
+	}`), &data), "Internal test error")
+
+	tests := []struct {
+		options Options
+		path    string
+	}{
+		{Options{MaxDepth: 2}, "root.list[0][0]"},
+		{Options{MaxArrayLength: 2}, "root.list"},
+		{Options{MaxStringLength: 5}, "root.list[2][0]"},
+	}
+	for _, schema := range []Schema{raw, Object{Properties: Properties{"raw": raw}}} {
+		for _, test := range tests {
+			value, path, options := data, test.path, test.options
+			if _, ok := schema.(Object); ok {
+				value = map[string]interface{}{"raw": data}
+				path = "root.raw" + path[len("root"):]
+				if options.MaxDepth > 0 {
+					options.MaxDepth++
+				}
+			}
+			err := ValidateContext(context.Background(), schema, value, options)
+			e, ok := err.(*ValidationError)
+			assert(ok, "Expected a ValidationError for options: ", test.options, err)
+			issues := e.Issues("root")
+			assert(len(issues) == 1 && issues[0].LimitExceeded(),
+				"Expected a single limit issue, got: ", err)
+			assert(issues[0].Path() == path, "Expected issue at ", path, " got: ", issues[0].Path())
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ValidateContext(ctx, raw, data, Options{})
+	assert(err == context.Canceled, "Expected context.Canceled, got: ", err)
+}
+
+func TestMapContextOptions(t *testing.T) {
+	var target map[string]interface{}
+	err := MapContext(context.Background(), optionsTestSchema, map[string]interface{}{
+		"name": "abc",
+	}, &target, Options{SkipRequired: true})
+	assert(err == nil, "Expected options to apply when mapping, got: ", err)
+	assert(target["name"] == "abc", "Expected name to be mapped, got: ", target)
+}
+
+func TestValidateContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ValidateContext(ctx, optionsTestSchema, optionsTestData(), Options{})
+	assert(err == context.Canceled, "Expected context.Canceled, got: ", err)
+
+	var target interface{}
+	err = MapContext(ctx, optionsTestSchema, optionsTestData(), &target, Options{})
+	assert(err == context.Canceled, "Expected context.Canceled, got: ", err)
+}
+
+func TestValidateWithWarnings(t *testing.T) {
+	s := Object{
+		Properties: Properties{
+			"items": Array{Items: Object{
+				Properties: Properties{
+					"name":  String{},
+					"label": String{},
+				},
+				Deprecated: []string{"label"},
+			}},
+			"mode": StringEnum{Options: []string{"fast", "slow"}, Deprecated: []string{"slow"}},
+			"level": AnyOf{
+				Object{Properties: Properties{"old": String{}}, Deprecated: []string{"old"}, Required: []string{"x"}},
+				Object{Properties: Properties{"old": String{}}},
+			},
+		},
+	}
+	var data interface{}
+	nilOrPanic(json.Unmarshal([]byte(`{
+		"items": [{"name": "a"}, {"name": "b", "label": "c"}],
+		"mode": "slow",
+		"level": {"old": "x"}
+	}`), &data), "Internal test error")
+
+	warnings, err := ValidateWithWarnings(s, data)
+	assert(err == nil, "Expected no error, got: ", err)
+	assert(len(warnings) == 2, "Expected 2 warnings, got: ", warnings)
+	assert(warnings[0].Path() == "root.items[1].label", "Unexpected path: ", warnings[0].Path())
+	assert(warnings[0].Keyword() == "deprecated", "Unexpected keyword: ", warnings[0].Keyword())
+	assert(warnings[1].Path() == "root.mode", "Unexpected path: ", warnings[1].Path())
+
+	// Validate shouldn't be affected by warnings
+	assert(s.Validate(data) == nil, "Expected no validation error")
+	assert(s.Schema()["properties"].(map[string]map[string]interface{})["items"] != nil,
+		"Expected items property")
+}
+
+func TestFilterWithWarnings(t *testing.T) {
+	s := Object{Properties: Properties{"name": String{}}}
+	result, warnings := s.FilterWithWarnings(map[string]interface{}{
+		"name":  "a",
+		"other": "b",
+	})
+	assert(len(result) == 1 && result["name"] == "a", "Unexpected result: ", result)
+	assert(len(warnings) == 1, "Expected a single warning")
+	assert(warnings[0].Path() == "root.other", "Unexpected path: ", warnings[0].Path())
+	assert(warnings[0].Keyword() == "additionalProperties", "Unexpected keyword")
+}
@@ -1,6 +1,8 @@
 package schematypes
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
 	"net/url"
 	"reflect"
@@ -41,29 +43,18 @@ func (i Integer) Schema() map[string]interface{} {
 // Validate the given data, this will return nil if data satisfies this schema.
 // Otherwise, Validate(data) returns a ValidationError instance.
 func (i Integer) Validate(data interface{}) error {
-	var value int64
-	v := reflect.ValueOf(data)
-	switch v.Kind() {
-	case reflect.Float32, reflect.Float64:
-		if float64(int64(v.Float())) != v.Float() {
-			return singleIssue("", "Expected an integer at {path}")
-		}
-		value = int64(v.Float())
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		value = v.Int()
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		value = int64(v.Uint())
-	default:
-		return singleIssue("", "Expected an integer at {path}")
+	value, ok := integerValue(data)
+	if !ok {
+		return singleIssue("type", "Expected an integer at {path}")
 	}
 
 	if value < i.Minimum {
-		return singleIssue("", "Integer %d at {path} is less than minimum %d",
+		return singleIssue("minimum", "Integer %d at {path} is less than minimum %d",
 			value, i.Minimum,
 		)
 	}
 	if value > i.Maximum {
-		return singleIssue("", "Integer %d at {path} is larger than maximum %d",
+		return singleIssue("maximum", "Integer %d at {path} is larger than maximum %d",
 			value, i.Maximum,
 		)
 	}
@@ -76,68 +67,91 @@ func (i Integer) Map(data interface{}, target interface{}) error {
 	if err := i.Validate(data); err != nil {
 		return err
 	}
+	return i.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (i Integer) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(i, data, target); ok {
+		return err
+	}
 
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(i, target)
 	}
 	val := ptr.Elem()
 
-	var value int64
-	v := reflect.ValueOf(data)
-	switch v.Kind() {
-	case reflect.Float32, reflect.Float64:
-		value = int64(v.Float())
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		value = v.Int()
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		value = int64(v.Uint())
-	default:
+	value, ok := integerValue(data)
+	if !ok {
 		panic("internal error -- validate should have caught this")
 	}
 
+	if !integerFits(val.Kind(), i.Minimum, i.Maximum) {
+		return typeMismatch(i, target)
+	}
 	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val.SetInt(value)
+	default:
+		val.SetUint(uint64(value))
+	}
+	return nil
+}
+
+// integerFits returns true, if integers from min to max always fit in the
+// integer kind k. The int and uint kinds are assumed to be 32 bits.
+func integerFits(k reflect.Kind, min, max int64) bool {
+	switch k {
 	case reflect.Int8:
-		if i.Minimum < math.MinInt8 || i.Maximum > math.MaxInt8 {
-			return ErrTypeMismatch
-		}
-		fallthrough
+		return min >= math.MinInt8 && max <= math.MaxInt8
 	case reflect.Int16:
-		if i.Minimum < math.MinInt16 || i.Maximum > math.MaxInt16 {
-			return ErrTypeMismatch
-		}
-		fallthrough
+		return min >= math.MinInt16 && max <= math.MaxInt16
 	case reflect.Int32, reflect.Int:
-		if i.Minimum < math.MinInt32 || i.Maximum > math.MaxInt32 {
-			return ErrTypeMismatch
-		}
-		fallthrough
+		return min >= math.MinInt32 && max <= math.MaxInt32
 	case reflect.Int64:
-		val.SetInt(value)
-		return nil
+		return true
 	case reflect.Uint8:
-		if i.Minimum < 0 || i.Maximum > math.MaxUint8 {
-			return ErrTypeMismatch
-		}
-		fallthrough
+		return min >= 0 && max <= math.MaxUint8
 	case reflect.Uint16:
-		if i.Minimum < 0 || i.Maximum > math.MaxUint16 {
-			return ErrTypeMismatch
-		}
-		fallthrough
+		return min >= 0 && max <= math.MaxUint16
 	case reflect.Uint32, reflect.Uint:
-		if i.Minimum < 0 || i.Maximum > math.MaxUint32 {
-			return ErrTypeMismatch
-		}
-		fallthrough
+		return min >= 0 && max <= math.MaxUint32
 	case reflect.Uint64:
-		if i.Minimum < 0 {
-			return ErrTypeMismatch
+		return min >= 0
+	}
+	return false
+}
+
+func (i Integer) unmap(value reflect.Value) (interface{}, error) {
+	return unmapInteger(i, value)
+}
+
+// unmapInteger returns value as int64, value must be an integer or a float
+// with an integral value.
+func unmapInteger(s Schema, value reflect.Value) (interface{}, error) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value.Uint() > math.MaxInt64 {
+			return nil, unmapMismatch(s, value)
 		}
-		val.SetUint(uint64(value))
-		return nil
+		return int64(value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		if float64(int64(value.Float())) != value.Float() {
+			return nil, unmapMismatch(s, value)
+		}
+		return int64(value.Float()), nil
+	case reflect.String:
+		if value.Type() == typeOfJSONNumber {
+			if i, ok := integerValue(value.Interface()); ok {
+				return i, nil
+			}
+		}
+		return nil, unmapMismatch(s, value)
 	default:
-		return ErrTypeMismatch
+		return nil, unmapMismatch(s, value)
 	}
 }
 
@@ -146,6 +160,8 @@ type IntegerEnum struct {
 	Title       string
 	Description string
 	Options     []int
+	// Deprecated options, validation gives a warning when these are used.
+	Deprecated []int
 }
 
 // Schema returns a JSON representation of the schema.
@@ -159,25 +175,14 @@ func (s IntegerEnum) Schema() map[string]interface{} {
 // Validate the given data, this will return nil if data satisfies this schema.
 // Otherwise, Validate(data) returns a ValidationError instance.
 func (s IntegerEnum) Validate(data interface{}) error {
-	var value int64
-	v := reflect.ValueOf(data)
-	switch v.Kind() {
-	case reflect.Float32, reflect.Float64:
-		if float64(int64(v.Float())) != v.Float() {
-			return singleIssue("", "Expected an integer at {path}")
-		}
-		value = int64(v.Float())
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		value = v.Int()
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		value = int64(v.Uint())
-	default:
-		return singleIssue("", "Expected an integer at {path}")
+	value, ok := integerValue(data)
+	if !ok {
+		return singleIssue("type", "Expected an integer at {path}")
 	}
 
 	if !intContains(s.Options, int(value)) {
 		e := &ValidationError{}
-		e.addIssue("",
+		e.addIssue("enum",
 			"Value '%d' at {path} is not valid for the enum with options: %v",
 			value, s.Options)
 		return e
@@ -186,18 +191,46 @@ func (s IntegerEnum) Validate(data interface{}) error {
 	return nil
 }
 
+func (s IntegerEnum) validate(v *validation, data interface{}) error {
+	if err := s.Validate(data); err != nil {
+		return err
+	}
+	for _, option := range s.Deprecated {
+		if jsonEqual(option, data) {
+			v.warn("deprecated", "Value '%d' at {path} is a deprecated option", option)
+		}
+	}
+	return nil
+}
+
 // Map takes data, validates and maps it into the target reference.
 func (s IntegerEnum) Map(data interface{}, target interface{}) error {
 	if err := s.Validate(data); err != nil {
 		return err
 	}
+	return s.mapValidated(data, target)
+}
 
-	// Find min and max, and create an Integer to use for Map
+// mapValidated maps data, which must be valid, into target.
+func (s IntegerEnum) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(s, data, target); ok {
+		return err
+	}
+
+	// Create an Integer with the min and max to use for Map
 	// We need min/max because if the enum contains an option that doesn't fit in
 	// a int8, when we don't want to allow mapping to int8, even if the actually
 	// value in the given instance matches. Map should either always work or
 	// never work, that way it's fairly reliable.
-	var min, max int64
+	min, max := s.bounds()
+	return Integer{
+		Minimum: min,
+		Maximum: max,
+	}.mapValidated(data, target)
+}
+
+// bounds returns the smallest and largest option.
+func (s IntegerEnum) bounds() (min, max int64) {
 	min = math.MaxInt64
 	max = math.MinInt64
 	for _, value := range s.Options {
@@ -208,11 +241,11 @@ func (s IntegerEnum) Map(data interface{}, target interface{}) error {
 			max = int64(value)
 		}
 	}
+	return min, max
+}
 
-	return Integer{
-		Minimum: min,
-		Maximum: max,
-	}.Map(data, target)
+func (s IntegerEnum) unmap(value reflect.Value) (interface{}, error) {
+	return unmapInteger(s, value)
 }
 
 // Number schema type.
@@ -221,6 +254,10 @@ type Number struct {
 	Description string
 	Minimum     float64
 	Maximum     float64
+	// MultipleOf requires numbers to be a multiple of this, zero for none.
+	// If MultipleOf is a whole number, numbers can be mapped into integer
+	// types that can hold the numbers from Minimum to Maximum.
+	MultipleOf float64
 }
 
 // Schema returns a JSON representation of the schema.
@@ -233,47 +270,160 @@ func (n Number) Schema() map[string]interface{} {
 	if n.Maximum != math.MaxFloat64 {
 		m["maximum"] = n.Maximum
 	}
+	if n.MultipleOf != 0 {
+		m["multipleOf"] = n.MultipleOf
+	}
 	return m
 }
 
 // Validate the given data, this will return nil if data satisfies this schema.
 // Otherwise, Validate(data) returns a ValidationError instance.
 func (n Number) Validate(data interface{}) error {
-	value, ok := data.(float64)
+	value, ok := floatValue(data)
 	if !ok {
-		return singleIssue("", "Expected a number at {path}")
+		return singleIssue("type", "Expected a number at {path}")
 	}
 	if value < n.Minimum {
-		return singleIssue("", "Number %d at {path} is less than minimum %d",
+		return singleIssue("minimum", "Number %g at {path} is less than minimum %g",
 			value, n.Minimum,
 		)
 	}
 	if value > n.Maximum {
-		return singleIssue("", "Number %d at {path} is larger than maximum %d",
+		return singleIssue("maximum", "Number %g at {path} is larger than maximum %g",
 			value, n.Maximum,
 		)
 	}
+	if n.MultipleOf != 0 && !isMultiple(value, n.MultipleOf) {
+		return singleIssue("multipleOf", "Number %g at {path} is not a multiple of %g",
+			value, n.MultipleOf,
+		)
+	}
 	return nil
 }
 
+// isMultiple returns true, if value is a multiple of m. This allows for
+// rounding errors of a few ULPs in the quotient, such that 0.3 is a multiple
+// of 0.1, but not for more, as value may be far from zero.
+func isMultiple(value, m float64) bool {
+	q := math.Abs(value / m)
+	ulp := math.Nextafter(math.Max(1, q), math.Inf(1)) - math.Max(1, q)
+	return math.Abs(q-math.Round(q)) <= 4*ulp
+}
+
+// integral returns true, if n only allows whole numbers.
+func (n Number) integral() bool {
+	return n.MultipleOf != 0 && n.MultipleOf == math.Trunc(n.MultipleOf)
+}
+
 // Map takes data, validates and maps it into the target reference.
 func (n Number) Map(data interface{}, target interface{}) error {
 	if err := n.Validate(data); err != nil {
 		return err
 	}
+	return n.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (n Number) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(n, data, target); ok {
+		return err
+	}
 
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(n, target)
 	}
 	val := ptr.Elem()
 
-	switch val.Kind() {
-	case reflect.Float32, reflect.Float64:
-		val.SetFloat(data.(float64))
+	// Allocate pointers, such as *float64
+	value, _ := floatValue(data)
+	if val.Kind() == reflect.Ptr {
+		elem := reflect.New(val.Type().Elem())
+		if reason := n.setValue(elem.Elem(), value); reason != "" {
+			e := typeMismatch(n, target)
+			e.Reason = reason
+			return e
+		}
+		val.Set(elem)
 		return nil
+	}
+	if reason := n.setValue(val, value); reason != "" {
+		e := typeMismatch(n, target)
+		e.Reason = reason
+		return e
+	}
+	return nil
+}
+
+// setValue sets val to value, or returns the reason val can't hold value.
+//
+// Integer kinds can hold value, if value is a whole number that the kind can
+// hold, and if n is integral, the kind must also hold the numbers from
+// n.Minimum to n.Maximum, like Integer.Map requires.
+func (n Number) setValue(val reflect.Value, value float64) string {
+	switch k := val.Kind(); k {
+	case reflect.Float32:
+		if math.Abs(value) > math.MaxFloat32 {
+			return fmt.Sprintf("%g overflows float32", value)
+		}
+		val.SetFloat(value)
+	case reflect.Float64:
+		val.SetFloat(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value != math.Trunc(value) {
+			return fmt.Sprintf("%g is not a whole number", value)
+		}
+		// Values beyond int64 are not supported, even for uint64
+		if value < math.MinInt64 || value >= -math.MinInt64 {
+			return fmt.Sprintf("%v can't hold %g", k, value)
+		}
+		if n.integral() && !integerFits(k, clampInt64(n.Minimum), clampInt64(n.Maximum)) {
+			return fmt.Sprintf("%v can't hold numbers from %g to %g", k, n.Minimum, n.Maximum)
+		}
+		if !integerFits(k, int64(value), int64(value)) {
+			return fmt.Sprintf("%v can't hold %g", k, value)
+		}
+		switch k {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			val.SetUint(uint64(value))
+		default:
+			val.SetInt(int64(value))
+		}
+	default:
+		return "expected a float or integer type"
+	}
+	return ""
+}
+
+// clampInt64 returns f as int64, clamped to the range of int64.
+func clampInt64(f float64) int64 {
+	if f < math.MinInt64 {
+		return math.MinInt64
+	}
+	if f >= -math.MinInt64 {
+		return math.MaxInt64
+	}
+	return int64(f)
+}
+
+func (n Number) unmap(value reflect.Value) (interface{}, error) {
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), nil
+	case reflect.String:
+		if value.Type() == typeOfJSONNumber {
+			if f, ok := floatValue(value.Interface()); ok {
+				return f, nil
+			}
+		}
+		return nil, unmapMismatch(n, value)
 	default:
-		return ErrTypeMismatch
+		return nil, unmapMismatch(n, value)
 	}
 }
 
@@ -294,7 +444,7 @@ func (b Boolean) Schema() map[string]interface{} {
 // Otherwise, Validate(data) returns a ValidationError instance.
 func (b Boolean) Validate(data interface{}) error {
 	if _, ok := data.(bool); !ok {
-		return singleIssue("", "Expected a boolean at {path}")
+		return singleIssue("type", "Expected a boolean at {path}")
 	}
 	return nil
 }
@@ -304,10 +454,18 @@ func (b Boolean) Map(data interface{}, target interface{}) error {
 	if err := b.Validate(data); err != nil {
 		return err
 	}
+	return b.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (b Boolean) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(b, data, target); ok {
+		return err
+	}
 
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(b, target)
 	}
 	val := ptr.Elem()
 
@@ -316,10 +474,17 @@ func (b Boolean) Map(data interface{}, target interface{}) error {
 		val.SetBool(data.(bool))
 		return nil
 	default:
-		return ErrTypeMismatch
+		return typeMismatch(b, target)
 	}
 }
 
+func (b Boolean) unmap(value reflect.Value) (interface{}, error) {
+	if value.Kind() != reflect.Bool {
+		return nil, unmapMismatch(b, value)
+	}
+	return value.Bool(), nil
+}
+
 // String schema type.
 type String struct {
 	Title         string
@@ -350,24 +515,24 @@ func (s String) Schema() map[string]interface{} {
 func (s String) Validate(data interface{}) error {
 	value, ok := data.(string)
 	if !ok {
-		return singleIssue("", "Expected a string at {path}")
+		return singleIssue("type", "Expected a string at {path}")
 	}
 
 	e := &ValidationError{}
 
 	if s.MinimumLength != 0 && len(value) < s.MinimumLength {
-		e.addIssue("",
+		e.addIssue("minLength",
 			"String '%s' at {path} is shorter than minimum %d length allowed",
 			value, s.MinimumLength)
 	}
 	if s.MaximumLength != 0 && len(value) > s.MaximumLength {
-		e.addIssue("",
+		e.addIssue("maxLength",
 			"String '%s' at {path} is longer than maximum %d length allowed",
 			value, s.MaximumLength)
 	}
 	if s.Pattern != "" {
 		if match, _ := regexp.MatchString(s.Pattern, value); !match {
-			e.addIssue("", "String '%s' at {path} doesn't match regular expression '%s'",
+			e.addIssue("pattern", "String '%s' at {path} doesn't match regular expression '%s'",
 				value, s.Pattern)
 		}
 	}
@@ -383,10 +548,18 @@ func (s String) Map(data interface{}, target interface{}) error {
 	if err := s.Validate(data); err != nil {
 		return err
 	}
+	return s.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (s String) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(s, data, target); ok {
+		return err
+	}
 
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
 	}
 	val := ptr.Elem()
 
@@ -395,15 +568,24 @@ func (s String) Map(data interface{}, target interface{}) error {
 		val.SetString(data.(string))
 		return nil
 	default:
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
 	}
 }
 
+func (s String) unmap(value reflect.Value) (interface{}, error) {
+	if value.Kind() != reflect.String {
+		return nil, unmapMismatch(s, value)
+	}
+	return value.String(), nil
+}
+
 // StringEnum schema type for enums of strings.
 type StringEnum struct {
 	Title       string
 	Description string
 	Options     []string
+	// Deprecated options, validation gives a warning when these are used.
+	Deprecated []string
 }
 
 // Schema returns a JSON representation of the schema.
@@ -419,12 +601,12 @@ func (s StringEnum) Schema() map[string]interface{} {
 func (s StringEnum) Validate(data interface{}) error {
 	value, ok := data.(string)
 	if !ok {
-		return singleIssue("", "Expected a string at {path}")
+		return singleIssue("type", "Expected a string at {path}")
 	}
 
 	if !stringContains(s.Options, value) {
 		e := &ValidationError{}
-		e.addIssue("",
+		e.addIssue("enum",
 			"Value '%s' at {path} is not valid for the enum with options: %v",
 			value, s.Options)
 		return e
@@ -433,15 +615,33 @@ func (s StringEnum) Validate(data interface{}) error {
 	return nil
 }
 
+func (s StringEnum) validate(v *validation, data interface{}) error {
+	if err := s.Validate(data); err != nil {
+		return err
+	}
+	if stringContains(s.Deprecated, data.(string)) {
+		v.warn("deprecated", "Value '%s' at {path} is a deprecated option", data)
+	}
+	return nil
+}
+
 // Map takes data, validates and maps it into the target reference.
 func (s StringEnum) Map(data interface{}, target interface{}) error {
 	if err := s.Validate(data); err != nil {
 		return err
 	}
+	return s.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (s StringEnum) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(s, data, target); ok {
+		return err
+	}
 
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
 	}
 	val := ptr.Elem()
 
@@ -450,10 +650,17 @@ func (s StringEnum) Map(data interface{}, target interface{}) error {
 		val.SetString(data.(string))
 		return nil
 	default:
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
 	}
 }
 
+func (s StringEnum) unmap(value reflect.Value) (interface{}, error) {
+	if value.Kind() != reflect.String {
+		return nil, unmapMismatch(s, value)
+	}
+	return value.String(), nil
+}
+
 // URI schema type for strings with format: uri.
 type URI struct {
 	Title       string
@@ -474,13 +681,13 @@ func (s URI) Schema() map[string]interface{} {
 func (s URI) Validate(data interface{}) error {
 	value, ok := data.(string)
 	if !ok {
-		return singleIssue("", "Expected a string at {path}")
+		return singleIssue("type", "Expected a string at {path}")
 	}
 
 	u, err := url.Parse(value)
 	if err != nil || u.Scheme == "" {
 		e := &ValidationError{}
-		e.addIssue("", "Value '%s' at {path} is not a valid URI", value)
+		e.addIssue("format", "Value '%s' at {path} is not a valid URI", value)
 		return e
 	}
 
@@ -494,10 +701,18 @@ func (s URI) Map(data interface{}, target interface{}) error {
 	if err := s.Validate(data); err != nil {
 		return err
 	}
+	return s.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (s URI) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(s, data, target); ok {
+		return err
+	}
 
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
 	}
 	val := ptr.Elem()
 
@@ -507,20 +722,32 @@ func (s URI) Map(data interface{}, target interface{}) error {
 		return nil
 	case reflect.Ptr:
 		if val.Type().Elem() != typeOfURL {
-			return ErrTypeMismatch
+			return typeMismatch(s, target)
 		}
 		u, _ := url.Parse(data.(string))
 		val.Set(reflect.ValueOf(u))
 		return nil
 	case reflect.Struct:
 		if val.Type() != typeOfURL {
-			return ErrTypeMismatch
+			return typeMismatch(s, target)
 		}
 		u, _ := url.Parse(data.(string))
 		val.Set(reflect.ValueOf(*u))
 		return nil
 	default:
-		return ErrTypeMismatch
+		return typeMismatch(s, target)
+	}
+}
+
+func (s URI) unmap(value reflect.Value) (interface{}, error) {
+	switch {
+	case value.Kind() == reflect.String:
+		return value.String(), nil
+	case value.Type() == typeOfURL:
+		u := value.Interface().(url.URL)
+		return u.String(), nil
+	default:
+		return nil, unmapMismatch(s, value)
 	}
 }
 
@@ -550,13 +777,13 @@ func parseDateTime(input string) (time.Time, error) {
 func (d DateTime) Validate(data interface{}) error {
 	value, ok := data.(string)
 	if !ok {
-		return singleIssue("", "Expected a string at {path}")
+		return singleIssue("type", "Expected a string at {path}")
 	}
 
 	// Try to parse date
 	if _, err := parseDateTime(value); err != nil {
 		e := &ValidationError{}
-		e.addIssue("", "Value '%s' at {path} is not a valid date-time string", value)
+		e.addIssue("format", "Value '%s' at {path} is not a valid date-time string", value)
 		return e
 	}
 
@@ -570,10 +797,18 @@ func (d DateTime) Map(data interface{}, target interface{}) error {
 	if err := d.Validate(data); err != nil {
 		return err
 	}
+	return d.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (d DateTime) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(d, data, target); ok {
+		return err
+	}
 
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(d, target)
 	}
 	val := ptr.Elem()
 
@@ -583,20 +818,31 @@ func (d DateTime) Map(data interface{}, target interface{}) error {
 		return nil
 	case reflect.Ptr:
 		if val.Type().Elem() != typeOfTime {
-			return ErrTypeMismatch
+			return typeMismatch(d, target)
 		}
 		t, _ := parseDateTime(data.(string))
 		val.Set(reflect.ValueOf(&t))
 		return nil
 	case reflect.Struct:
 		if val.Type() != typeOfTime {
-			return ErrTypeMismatch
+			return typeMismatch(d, target)
 		}
 		t, _ := parseDateTime(data.(string))
 		val.Set(reflect.ValueOf(t))
 		return nil
 	default:
-		return ErrTypeMismatch
+		return typeMismatch(d, target)
+	}
+}
+
+func (d DateTime) unmap(value reflect.Value) (interface{}, error) {
+	switch {
+	case value.Kind() == reflect.String:
+		return value.String(), nil
+	case value.Type() == typeOfTime:
+		return value.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	default:
+		return nil, unmapMismatch(d, value)
 	}
 }
 
@@ -647,11 +893,17 @@ func (d Duration) Validate(data interface{}) error {
 	switch v.Kind() {
 	case reflect.Float32, reflect.Float64:
 		if float64(int64(v.Float())) != v.Float() {
-			return singleIssue("", "Expected an integer duration at {path}")
+			return singleIssue("type", "Expected an integer duration at {path}")
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 	case reflect.String:
+		if _, ok := data.(json.Number); ok {
+			if _, ok := integerValue(data); !ok {
+				return singleIssue("type", "Expected an integer duration at {path}")
+			}
+			return nil
+		}
 		var pattern *regexp.Regexp
 		if d.AllowNegative {
 			pattern = signedDurationRegexp
@@ -659,11 +911,11 @@ func (d Duration) Validate(data interface{}) error {
 			pattern = durationRegexp
 		}
 		if !pattern.MatchString(v.String()) {
-			return singleIssue("", "String '%s' at {path} doesn't match duration pattern '%s'",
+			return singleIssue("pattern", "String '%s' at {path} doesn't match duration pattern '%s'",
 				v.String(), pattern.String())
 		}
 	default:
-		return singleIssue("", "Expected an integer or string duration at {path}")
+		return singleIssue("type", "Expected an integer or string duration at {path}")
 	}
 	return nil
 }
@@ -675,16 +927,28 @@ func (d Duration) Map(data interface{}, target interface{}) error {
 	if err := d.Validate(data); err != nil {
 		return err
 	}
+	return d.mapValidated(data, target)
+}
+
+// mapValidated maps data, which must be valid, into target.
+func (d Duration) mapValidated(data, target interface{}) error {
+	if ok, err := mapHook(d, data, target); ok {
+		return err
+	}
 
 	ptr := reflect.ValueOf(target)
 	if ptr.Kind() != reflect.Ptr {
-		return ErrTypeMismatch
+		return typeMismatch(d, target)
 	}
 	val := ptr.Elem()
 
 	// find duration as result
 	v := reflect.ValueOf(data)
 	var result time.Duration
+	if seconds, ok := data.(json.Number); ok {
+		value, _ := integerValue(seconds)
+		v = reflect.ValueOf(value)
+	}
 	switch v.Kind() {
 	case reflect.Float32, reflect.Float64:
 		result = time.Duration(v.Float()) * time.Second
@@ -715,8 +979,43 @@ func (d Duration) Map(data interface{}, target interface{}) error {
 	}
 
 	if val.Type() != typeOfDuration {
-		return ErrTypeMismatch
+		return typeMismatch(d, target)
 	}
 	val.Set(reflect.ValueOf(result))
 	return nil
 }
+
+// unmap returns a duration string, such as '1d 2h 3m', or integer seconds if
+// the duration isn't a whole number of minutes.
+func (d Duration) unmap(value reflect.Value) (interface{}, error) {
+	if value.Type() != typeOfDuration {
+		return nil, unmapMismatch(d, value)
+	}
+	duration := time.Duration(value.Int())
+	if duration%time.Second != 0 {
+		return nil, unmapMismatch(d, value)
+	}
+	if duration%time.Minute != 0 {
+		return int64(duration / time.Second), nil
+	}
+
+	sign := ""
+	if duration < 0 {
+		sign = "- "
+		duration = -duration
+	}
+	days := duration / (24 * time.Hour)
+	hours := duration % (24 * time.Hour) / time.Hour
+	minutes := duration % time.Hour / time.Minute
+	parts := []string{}
+	if days != 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours != 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes != 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return sign + strings.Join(parts, " "), nil
+}
@@ -2,6 +2,8 @@ package schematypes
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/url"
 	"testing"
 	"time"
@@ -142,6 +144,8 @@ func TestIntegerEnum(t *testing.T) {
 	}.Test(t)
 }
 
+type testNumberFloat float64
+
 func TestNumber(t *testing.T) {
 	var a float64
 	var b float32
@@ -151,6 +155,8 @@ func TestNumber(t *testing.T) {
 	var f struct{ float64 }
 	var g uint64
 	var h int8
+	var i *float64
+	var j testNumberFloat
 	testCase{
 		Schema: Number{
 			Title:       "my-title",
@@ -172,12 +178,87 @@ func TestNumber(t *testing.T) {
 			"242", "-254",
 		},
 		TypeMatch: []interface{}{
-			&a, &b,
+			&a, &b, &i, &j,
 		},
 		TypeMismatch: []interface{}{
-			&c, &d, &e, &f, &g, &h,
+			&d, &e, &f,
 		},
 	}.Test(t)
+
+	// Integer types can hold whole numbers only
+	nilOrPanic(Number{Minimum: -100, Maximum: 100}.Map(float64(-32), &c), "Expected Map to succeed")
+	assert(c == -32, "Unexpected value: ", c)
+	err := Number{Minimum: -100, Maximum: 100}.Map(32.5, &c)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+	err = Number{Minimum: -1000, Maximum: 1000}.Map(float64(-32), &g)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+	err = Number{Minimum: -1000, Maximum: 1000}.Map(float64(200), &h)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+}
+
+func TestNumberMultipleOf(t *testing.T) {
+	var a int8
+	var b uint16
+	var c float32
+	var d int32
+	testCase{
+		Schema: Number{
+			Minimum:    0,
+			Maximum:    240,
+			MultipleOf: 2,
+		},
+		Match: `{
+      "type": "number",
+      "minimum": 0,
+      "maximum": 240,
+      "multipleOf": 2
+    }`,
+		Valid: []string{
+			"0", "2", "240", "64.0",
+		},
+		Invalid: []string{
+			"3", "2.5", "242",
+		},
+		TypeMatch: []interface{}{
+			&b, &c, &d,
+		},
+		TypeMismatch: []interface{}{
+			&a,
+		},
+	}.Test(t)
+
+	// Rounding errors are allowed
+	err := Number{Minimum: 0, Maximum: 1, MultipleOf: 0.1}.Validate(0.3)
+	assert(err == nil, "Expected 0.3 to be a multiple of 0.1, got: ", err)
+}
+
+func TestNumberLargeValues(t *testing.T) {
+	s := Number{Minimum: -math.MaxFloat64, Maximum: math.MaxFloat64, MultipleOf: 1}
+	err := s.Validate(1e10 + 0.5)
+	assert(err != nil, "Expected 1e10+0.5 not to be a multiple of 1")
+	nilOrPanic(s.Validate(1e10), "Expected 1e10 to be a multiple of 1")
+	nilOrPanic(s.Validate(1e300), "Expected 1e300 to be a multiple of 1")
+	err = Number{Minimum: 0, Maximum: math.MaxFloat64, MultipleOf: 0.1}.Validate(1e9 + 1.1)
+	assert(err == nil, "Expected 1e9+1.1 to be a multiple of 0.1, got: ", err)
+
+	var i int64
+	err = s.Map(1e10+0.5, &i)
+	assert(err != nil && i == 0, "Expected Map to fail, got: ", i, err)
+	err = s.mapValidated(1e10+0.5, &i)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+	assert(i == 0, "Expected the value not to be truncated, got: ", i)
+	nilOrPanic(s.Map(1e10, &i), "Expected Map to succeed")
+	assert(i == 1e10, "Unexpected value: ", i)
+}
+
+func TestNumberMapFloat32(t *testing.T) {
+	var f float32
+	s := Number{Minimum: -math.MaxFloat64, Maximum: math.MaxFloat64}
+	nilOrPanic(s.Map(1.5, &f), "Expected Map to succeed")
+	assert(f == 1.5, "Unexpected value: ", f)
+	err := s.Map(1e39, &f)
+	assert(errors.Is(err, ErrTypeMismatch), "Expected a type mismatch, got: ", err)
+	assert(err.(*TypeMismatchError).Reason == "1e+39 overflows float32", "Unexpected error: ", err)
 }
 
 func TestBoolean(t *testing.T) {